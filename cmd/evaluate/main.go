@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/dataset"
+	"ocr_cnn/pkg/metrics"
+	"ocr_cnn/pkg/neuron"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// selectSplit recreates the split made by train, which needs the same seed and fractions
+func selectSplit(entries []dataset.Entry, name string, validationFraction, testFraction float64, seed uint64) []dataset.Entry {
+	if name == "all" {
+		return entries
+	}
+
+	split, err := dataset.SplitEntries(entries, validationFraction, testFraction, common.NewRand(seed, common.SplitStream))
+	if err != nil {
+		common.PrintAndTerminate(err.Error())
+	}
+	switch name {
+	case "train":
+		return split.Train
+	case "validation":
+		return split.Validation
+	case "test":
+		return split.Test
+	}
+
+	common.PrintAndTerminate(fmt.Sprintf("unknown split: %s", name))
+	return nil
+}
+
+// matchClasses maps every dataset label to the output of the model with the same label
+func matchClasses(datasetLabels, modelLabels []string) []int {
+	modelClasses := make([]int, len(datasetLabels))
+	for i, label := range datasetLabels {
+		modelClasses[i] = slices.Index(modelLabels, label)
+		if modelClasses[i] < 0 {
+			common.PrintAndTerminate(fmt.Sprintf("the model has no output for class %q", label))
+		}
+	}
+	return modelClasses
+}
+
+// formats writeReport knows
+var reportFormats = []string{"text", "csv", "json"}
+
+func writeReport(writer io.Writer, report metrics.Report, format string) error {
+	switch format {
+	case "text":
+		return report.WriteText(writer)
+	case "csv":
+		return report.WriteCSV(writer)
+	case "json":
+		return report.WriteJSON(writer)
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+func main() {
+	wd, _ := os.Getwd()
+
+	model_file := flag.String("model", path.Join(wd, "model.bin"), "model saved by train")
+	dataset_dir := flag.String("dataset", path.Join(wd, "translated_dataset"), "directory with one sub directory of images per class, or a labels.txt listing them, or a file written by pack_dataset")
+	idx_images := flag.String("idx-images", "", "IDX image file, such as MNIST's, to use instead of -dataset")
+	idx_labels := flag.String("idx-labels", "", "IDX label file that goes with -idx-images")
+	idx_manifest := flag.String("idx-manifest", "", "labels.txt naming the class of every IDX label value, such as the one export_idx writes")
+	idx_transpose := flag.Bool("idx-transpose", false, "IDX images are stored column by column, as in EMNIST")
+	split_name := flag.String("split", "test", "images to evaluate: train, validation, test or all")
+	validationFraction := flag.Float64("validation", .1, "validation share used by train")
+	testFraction := flag.Float64("test", .1, "test share used by train")
+	seed := flag.Uint64("seed", 1, "seed used by train")
+	format := flag.String("format", "text", fmt.Sprintf("report format: %s", strings.Join(reportFormats, ", ")))
+	output_file := flag.String("output", "", "file to write the report to instead of stdout")
+	image_size := flag.Int("size", 0, "width and height images are resized to, taken from the model when 0")
+	flag.Parse()
+
+	if !slices.Contains(reportFormats, *format) {
+		common.PrintAndTerminate(fmt.Sprintf("unknown format: %s", *format))
+	}
+
+	ann, err := neuron.LoadFile(*model_file)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not load model: %s %s", *model_file, err.Error()))
+	}
+	size, err := ann.ImageSize(*image_size)
+	if err != nil {
+		common.PrintAndTerminate(err.Error())
+	}
+
+	all, err := dataset.Open(*dataset_dir, *idx_images, *idx_labels, *idx_manifest, *idx_transpose)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not open dataset: %s", err.Error()))
+	}
+	defer all.Close()
+	images := all.Subset(selectSplit(all.List(), *split_name, *validationFraction, *testFraction, *seed))
+	if images.Len() == 0 {
+		common.PrintAndTerminate(fmt.Sprintf("no images in the %s split of: %s", *split_name, *dataset_dir))
+	}
+	modelClasses := matchClasses(images.Labels(), ann.Labels)
+
+	evaluation := metrics.CreateEvaluation(ann.Labels)
+	for sample, err := range dataset.All(images) {
+		if err != nil {
+			common.PrintAndTerminate(err.Error())
+		}
+		img := common.Resize(common.Binarize(sample.Image), size, size)
+
+		ann.InputEncoding(img)
+		ann.ForwardPropagation()
+
+		evaluation.Add(modelClasses[sample.Label], common.Argmax(ann.OutputVector()), sample.Font)
+	}
+
+	writer := io.Writer(os.Stdout)
+	if *output_file != "" {
+		file, err := os.Create(*output_file)
+		if err != nil {
+			common.PrintAndTerminate(fmt.Sprintf("could not create file: %s", *output_file))
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	if err := writeReport(writer, evaluation.Report(), *format); err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not write report: %s", err.Error()))
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/dataset"
+	"os"
+	"path"
+	"strings"
+)
+
+func main() {
+	wd, _ := os.Getwd()
+	dataset_dir := flag.String("dataset", path.Join(wd, "translated_dataset"), "directory with one sub directory of images per class, or a labels.txt listing them")
+	output_dir := flag.String("output", path.Join(wd, "idx"), "directory the IDX files are written to")
+	prefix := flag.String("prefix", "ocr", "start of every file name")
+	gzipped := flag.Bool("gzip", false, "gzip the IDX files")
+	flag.Parse()
+
+	folder, err := dataset.OpenFolder(*dataset_dir)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not open dataset: %s", err.Error()))
+	}
+	if err := os.MkdirAll(*output_dir, 0755); err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not create output directory: %s", *output_dir))
+	}
+
+	extension := ""
+	if *gzipped {
+		extension = ".gz"
+	}
+	images_file := path.Join(*output_dir, *prefix+"-images-idx3-ubyte"+extension)
+	labels_file := path.Join(*output_dir, *prefix+"-labels-idx1-ubyte"+extension)
+
+	images, labels, err := dataset.ExportIDX(folder)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not export dataset: %s", err.Error()))
+	}
+
+	for file_name, array := range map[string]dataset.IDXArray{images_file: images, labels_file: labels} {
+		if err := dataset.WriteIDXFile(file_name, array); err != nil {
+			common.PrintAndTerminate(fmt.Sprintf("could not write file: %s %s", file_name, err.Error()))
+		}
+	}
+
+	// one label per line, in the order of the label values
+	labels_manifest := path.Join(*output_dir, *prefix+"-"+dataset.LabelManifest)
+	if err := os.WriteFile(labels_manifest, []byte(strings.Join(folder.Labels(), "\n")+"\n"), 0644); err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not write file: %s", labels_manifest))
+	}
+
+	common.Log(fmt.Sprintf("exported %d images of %dx%d and %d classes to: %s", images.Dims[0], images.Dims[2], images.Dims[1], len(folder.Classes), *output_dir))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/dataset"
+	"os"
+	"path"
+)
+
+func main() {
+	wd, _ := os.Getwd()
+	dataset_dir := flag.String("dataset", path.Join(wd, "translated_dataset"), "directory with one sub directory of images per class, or a labels.txt listing them")
+	output_file := flag.String("output", path.Join(wd, "translated_dataset.pack"), "packed file to write")
+	flag.Parse()
+
+	folder, err := dataset.OpenFolder(*dataset_dir)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not open dataset: %s", err.Error()))
+	}
+
+	if err := dataset.WritePackedFile(*output_file, folder); err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not pack dataset: %s", err.Error()))
+	}
+
+	packed, err := dataset.OpenPacked(*output_file)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not read back: %s %s", *output_file, err.Error()))
+	}
+	defer packed.Close()
+
+	common.Log(fmt.Sprintf("packed %d images of %dx%d, %d classes and %d fonts into: %s",
+		packed.Len(), packed.Width, packed.Height, len(packed.Classes), len(packed.Fonts), *output_file))
+}
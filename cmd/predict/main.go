@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/dataset"
+	"ocr_cnn/pkg/neuron"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// expands every directory argument into the png files it contains
+func findImages(args []string) []string {
+	image_paths := []string{}
+
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			common.PrintAndTerminate(fmt.Sprintf("could not find: %s", arg))
+		}
+
+		if !info.IsDir() {
+			image_paths = append(image_paths, arg)
+			continue
+		}
+
+		dir_iterator, err := os.ReadDir(arg)
+		if err != nil {
+			common.PrintAndTerminate(fmt.Sprintf("could not read dir: %s", arg))
+		}
+		for _, file_entry := range dir_iterator {
+			if !file_entry.IsDir() && strings.EqualFold(path.Ext(file_entry.Name()), ".png") {
+				image_paths = append(image_paths, path.Join(arg, file_entry.Name()))
+			}
+		}
+	}
+
+	return image_paths
+}
+
+// the k most probable classes, most probable first
+func topK(probabilities []float64, k int) []int {
+	classes := make([]int, len(probabilities))
+	for i := range classes {
+		classes[i] = i
+	}
+
+	slices.SortStableFunc(classes, func(a, b int) int {
+		if probabilities[a] > probabilities[b] {
+			return -1
+		}
+		if probabilities[a] < probabilities[b] {
+			return 1
+		}
+		return 0
+	})
+
+	return classes[:min(k, len(classes))]
+}
+
+func main() {
+	wd, _ := os.Getwd()
+	model_file := flag.String("model", path.Join(wd, "model.bin"), "model saved by train")
+	k := flag.Int("k", 3, "number of classes to print for every image")
+	image_size := flag.Int("size", 0, "width and height images are resized to, taken from the model when 0")
+	flag.Usage = func() {
+		common.Log("usage: predict [flags] image.png|directory ...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *k < 1 {
+		common.PrintAndTerminate(fmt.Sprintf("invalid k %d, at least one class is printed", *k))
+	}
+
+	ann, err := neuron.LoadFile(*model_file)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not load model: %s %s", *model_file, err.Error()))
+	}
+	size, err := ann.ImageSize(*image_size)
+	if err != nil {
+		common.PrintAndTerminate(err.Error())
+	}
+
+	for _, image_path := range findImages(flag.Args()) {
+		img, err := dataset.LoadImage(image_path)
+		if err != nil {
+			common.PrintAndTerminate(err.Error())
+		}
+		img = common.Resize(common.Binarize(img), size, size)
+
+		ann.InputEncoding(img)
+		ann.ForwardPropagation()
+		probabilities := ann.OutputVector()
+
+		predictions := []string{}
+		for _, class := range topK(probabilities, *k) {
+			label := fmt.Sprintf("%d", class)
+			if class < len(ann.Labels) {
+				label = ann.Labels[class]
+			}
+			predictions = append(predictions, fmt.Sprintf("%s (%.4f)", label, probabilities[class]))
+		}
+
+		common.Log(fmt.Sprintf("%s: %s", image_path, strings.Join(predictions, ", ")))
+	}
+}
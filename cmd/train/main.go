@@ -1,97 +1,358 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"image"
-	"image/png"
+	"math/rand/v2"
+	"ocr_cnn/pkg/augment"
 	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/dataset"
 	"ocr_cnn/pkg/neuron"
+	"ocr_cnn/pkg/tensor"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 )
 
-func findResolution(dataset_dir string) (int, int) {
-	file_path := path.Join(dataset_dir, "0", "Abadi_0.png")
-	file_contents, err := os.ReadFile(file_path)
-	if err != nil {
-		common.PrintAndTerminate(fmt.Sprintf("could not read file: %s", file_path))
+// sample is an encoded image and the one hot encoding of its label. image is
+// only kept for augmentation
+type sample struct {
+	input    []float64
+	expected []float64
+	image    image.Image
+}
+
+func loadSamples(images dataset.Dataset, keepImages bool) ([]sample, error) {
+	samples := make([]sample, 0, images.Len())
+
+	for loaded, err := range dataset.All(images) {
+		if err != nil {
+			return nil, err
+		}
+
+		expectedOneHotEncoding := make([]float64, len(images.Labels())) // one output per class
+		expectedOneHotEncoding[loaded.Label] = 1                        // onehot encoding value maps to the label
+
+		binarized := common.Binarize(loaded.Image) // translated images already are, IDX images are gray
+		encoded := sample{input: neuron.EncodeImage(binarized), expected: expectedOneHotEncoding}
+		if keepImages {
+			encoded.image = binarized
+		}
+		samples = append(samples, encoded)
 	}
-	img, err := png.Decode(bytes.NewReader(file_contents))
-	if err != nil {
-		common.PrintAndTerminate(fmt.Sprintf("could not read png: %s", file_path))
+
+	return samples, nil
+}
+
+// augmentSamples encodes a freshly augmented copy of the image of every sample
+func augmentSamples(samples []sample, augmentation augment.Transform, rng *rand.Rand) []sample {
+	augmented := make([]sample, len(samples))
+	for i, original := range samples {
+		augmented[i] = sample{
+			input:    neuron.EncodeImage(augmentation(original.image, rng)),
+			expected: original.expected,
+		}
+	}
+	return augmented
+}
+
+// batch stacks the samples into one row per sample
+func batch(samples []sample) (*tensor.Tensor, *tensor.Tensor) {
+	inputSize, outputSize := len(samples[0].input), len(samples[0].expected)
+	inputs := tensor.New(len(samples), inputSize)
+	expected := tensor.New(len(samples), outputSize)
+
+	for i, sample := range samples {
+		copy(inputs.Row(i), sample.input)
+		copy(expected.Row(i), sample.expected)
+	}
+
+	return inputs, expected
+}
+
+// evaluate scores the samples in batches without training on them
+func evaluate(ann *neuron.ANN, samples []sample, batchSize int) (float64, int) {
+	loss := float64(0)
+	correct := 0
+	for start := 0; start < len(samples); start += batchSize {
+		inputs, expected := batch(samples[start:min(start+batchSize, len(samples))])
+		batchLoss, batchCorrect := ann.Evaluate(inputs, expected)
+		loss += batchLoss
+		correct += batchCorrect
+	}
+	return loss, correct
+}
+
+// scheduleFlags configure the learning rate schedule
+type scheduleFlags struct {
+	name             string
+	learningRate     float64
+	minLearningRate  float64
+	decayFactor      float64
+	decaySteps       int
+	cosinePeriod     int
+	cosineMultiplier int
+	patience         int
+	warmup           int
+}
+
+func createScheduler(flags scheduleFlags) neuron.Scheduler {
+	var scheduler neuron.Scheduler
+
+	switch flags.name {
+	case "constant":
+		scheduler = &neuron.ConstantRate{Rate: flags.learningRate}
+	case "step":
+		scheduler = neuron.CreateStepDecay(flags.learningRate, flags.decayFactor, flags.decaySteps)
+	case "exponential":
+		scheduler = &neuron.ExponentialDecay{Initial: flags.learningRate, Decay: flags.decayFactor}
+	case "cosine":
+		scheduler = neuron.CreateCosineAnnealing(flags.learningRate, flags.minLearningRate, flags.cosinePeriod, flags.cosineMultiplier)
+	case "plateau":
+		scheduler = neuron.CreateReduceOnPlateau(flags.learningRate, flags.decayFactor, flags.patience, flags.minLearningRate)
+	default:
+		common.PrintAndTerminate(fmt.Sprintf("unknown schedule: %s", flags.name))
+	}
+
+	if flags.warmup > 0 {
+		scheduler = &neuron.Warmup{Epochs: flags.warmup, After: scheduler}
+	}
+	return scheduler
+}
+
+// parseActivations reads a comma separated list of activation names, one per
+// hidden layer, where options follow the name after colons as in leaky_relu:0.1
+func parseActivations(value string) []func() neuron.Layer {
+	createActivations := []func() neuron.Layer{}
+
+	for _, field := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		options := []float64{}
+		for _, part := range parts[1:] {
+			option, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				common.PrintAndTerminate(fmt.Sprintf("invalid option of activation %s: %s", parts[0], part))
+			}
+			options = append(options, option)
+		}
+
+		if _, err := neuron.CreateActivation(parts[0], options...); err != nil {
+			common.PrintAndTerminate(err.Error())
+		}
+		createActivations = append(createActivations, func() neuron.Layer {
+			activation, _ := neuron.CreateActivation(parts[0], options...)
+			return activation
+		})
 	}
 
-	bounds := img.Bounds()
+	return createActivations
+}
+
+// parseAugmentation reads a comma separated list of transform names, applied
+// in order, where options follow the name after colons as in rotate:15. every
+// transform is applied to a share probability of the images
+func parseAugmentation(value string, probability float64) augment.Transform {
+	if value == "none" {
+		return nil
+	}
+	if !(probability >= 0 && probability <= 1) {
+		common.PrintAndTerminate(fmt.Sprintf("invalid augment probability: %g", probability))
+	}
+
+	transforms := []augment.Transform{}
+	for _, field := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		options := []float64{}
+		for _, part := range parts[1:] {
+			option, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				common.PrintAndTerminate(fmt.Sprintf("invalid option of transform %s: %s", parts[0], part))
+			}
+			options = append(options, option)
+		}
+
+		transform, err := augment.CreateTransform(parts[0], options...)
+		if err != nil {
+			common.PrintAndTerminate(err.Error())
+		}
+		transforms = append(transforms, augment.Maybe(probability, transform))
+	}
+
+	return augment.Pipeline(transforms...)
+}
 
-	x := bounds.Max.X - bounds.Min.X
-	y := bounds.Max.Y - bounds.Min.Y
+func createNormalization(name string) func(size int) neuron.Layer {
+	switch name {
+	case "none":
+		return nil
+	case "batch":
+		return func(size int) neuron.Layer { return neuron.CreateBatchNorm(size, .9, 1e-5) }
+	case "layer":
+		return func(size int) neuron.Layer { return neuron.CreateLayerNorm(size, 1e-5) }
+	}
 
-	return x, y
+	common.PrintAndTerminate(fmt.Sprintf("unknown normalization: %s", name))
+	return nil
 }
 
 func main() {
 	wd, _ := os.Getwd()
-	dataset_dir := path.Join(wd, "translated_dataset")
 
-	resolutionX, resolutionY := findResolution(dataset_dir)
-	layerSize := resolutionX * resolutionY
-	const numberOfHiddenLayers = 2
+	dataset_dir := flag.String("dataset", path.Join(wd, "translated_dataset"), "directory with one sub directory of images per class, or a labels.txt listing them, or a file written by pack_dataset")
+	idx_images := flag.String("idx-images", "", "IDX image file, such as MNIST's, to use instead of -dataset")
+	idx_labels := flag.String("idx-labels", "", "IDX label file that goes with -idx-images")
+	idx_manifest := flag.String("idx-manifest", "", "labels.txt naming the class of every IDX label value, such as the one export_idx writes")
+	idx_transpose := flag.Bool("idx-transpose", false, "IDX images are stored column by column, as in EMNIST")
+	model_file := flag.String("model", path.Join(wd, "model.bin"), "where to save the trained model, as JSON when it ends in .json")
+	epochs := flag.Int("epochs", 10, "number of passes over the dataset")
+	batchSize := flag.Int("batch-size", 32, "number of images per gradient update")
+	schedule := scheduleFlags{}
+	flag.Float64Var(&schedule.learningRate, "learning-rate", .0001, "step size of every gradient update, the first one of a schedule")
+	flag.StringVar(&schedule.name, "schedule", "constant", "learning rate schedule: constant, step, exponential, cosine or plateau")
+	flag.Float64Var(&schedule.minLearningRate, "min-learning-rate", 0, "lowest learning rate of the cosine and plateau schedules")
+	flag.Float64Var(&schedule.decayFactor, "decay-factor", .5, "factor the step, exponential and plateau schedules multiply the learning rate by")
+	flag.IntVar(&schedule.decaySteps, "decay-steps", 5, "epochs between two decays of the step schedule")
+	flag.IntVar(&schedule.cosinePeriod, "cosine-period", 10, "epochs until the cosine schedule restarts")
+	flag.IntVar(&schedule.cosineMultiplier, "cosine-multiplier", 1, "factor the cosine period grows by after every restart")
+	flag.IntVar(&schedule.patience, "patience", 2, "epochs without a lower validation loss before the plateau schedule decays")
+	flag.IntVar(&schedule.warmup, "warmup", 0, "epochs to linearly raise the learning rate before the schedule starts")
+	activations := flag.String("activation", "relu", fmt.Sprintf("hidden layer activations, comma separated per layer with options after colons: %s", strings.Join(neuron.ActivationNames(), ", ")))
+	numberOfHiddenLayers := flag.Int("hidden-layers", 2, "number of hidden layers, each half the size of the previous one")
+	normalization := flag.String("norm", "none", "normalization between every hidden layer and its activation: none, batch or layer")
+	regularization := neuron.Regularization{}
+	flag.Float64Var(&regularization.L1, "l1", 0, "L1 penalty on the weights")
+	flag.Float64Var(&regularization.L2, "l2", 0, "L2 penalty on the weights")
+	flag.Float64Var(&regularization.ClipValue, "clip-value", 0, "largest magnitude of a single gradient, 0 disables")
+	flag.Float64Var(&regularization.ClipNorm, "clip-norm", 0, "largest norm of all gradients together, 0 disables")
+	dropoutRate := flag.Float64("dropout", 0, "probability of dropping every hidden activation while training, 0 disables dropout")
+	weight_init := flag.String("init", "he_normal", fmt.Sprintf("weight initializer: %s", strings.Join(common.InitializerNames(), ", ")))
+	biasInit := flag.Float64("bias-init", 0, "value every bias starts at")
+	optimizer_name := flag.String("optimizer", "adam", fmt.Sprintf("how gradients are applied: %s", strings.Join(neuron.OptimizerNames(), ", ")))
+	seed := flag.Uint64("seed", 1, "seed of every random draw, the same seed trains the same model")
+	validationFraction := flag.Float64("validation", .1, "share of the font families held out for validation")
+	testFraction := flag.Float64("test", .1, "share of the font families held out for testing")
+	augmentations := flag.String("augment", "none", fmt.Sprintf("transforms applied to the training images every epoch, comma separated with options after colons, or none: %s", strings.Join(augment.TransformNames(), ", ")))
+	augmentProbability := flag.Float64("augment-probability", .5, "probability of applying every -augment transform to an image")
+	flag.Parse()
 
-	ann := neuron.CreateANN(common.NormalDistributionHe(), layerSize, numberOfHiddenLayers)
+	if *epochs <= 0 || *batchSize <= 0 {
+		common.PrintAndTerminate(fmt.Sprintf("invalid epochs %d or batch size %d", *epochs, *batchSize))
+	}
+	augmentation := parseAugmentation(*augmentations, *augmentProbability)
 
-	common.Log(fmt.Sprintf("created %d input layer neurons", len(ann.InputLayer)))
-	common.Log(fmt.Sprintf("created %d first hidden layer neurons", len(ann.InputLayer[0].Output)))
-	common.Log(fmt.Sprintf("created %d second hidden layer neurons", len(ann.OutputLayer[0].Input)))
-	common.Log(fmt.Sprintf("created %d output layer neurons", len(ann.OutputLayer)))
+	common.Log(fmt.Sprintf("seed: %d", *seed))
 
-	loss := float64(0)
-	for i := 0; i <= 9; i++ {
-		common.Debug(fmt.Sprintf("loading image %d", i))
-		img := common.GetImage(fmt.Sprintf("%d", i), 0)
-		loss += singlePassWithImage(&ann, img, i)
+	images, err := dataset.Open(*dataset_dir, *idx_images, *idx_labels, *idx_manifest, *idx_transpose)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not open dataset: %s", err.Error()))
 	}
-	mean := loss / 10
+	labels := images.Labels()
+	common.Log(fmt.Sprintf("classes: %s", strings.Join(labels, " ")))
 
-	common.Log(fmt.Sprintf("mean: %f", mean))
-	common.Log("done")
-}
+	split, err := dataset.SplitEntries(images.List(), *validationFraction, *testFraction, common.NewRand(*seed, common.SplitStream))
+	if err != nil {
+		common.PrintAndTerminate(err.Error())
+	}
+	samples, err := loadSamples(images.Subset(split.Train), augmentation != nil)
+	if err != nil {
+		common.PrintAndTerminate(err.Error())
+	}
+	validationSamples, err := loadSamples(images.Subset(split.Validation), false)
+	if err != nil {
+		common.PrintAndTerminate(err.Error())
+	}
+	if err := images.Close(); err != nil { // every sample is encoded in memory now
+		common.PrintAndTerminate(fmt.Sprintf("could not close dataset: %s", err.Error()))
+	}
+	if len(samples) == 0 {
+		common.PrintAndTerminate(fmt.Sprintf("no training images found in: %s", *dataset_dir))
+	}
+	common.Log(fmt.Sprintf("loaded %d training, %d validation and %d test images",
+		len(samples), len(validationSamples), len(split.Test)))
 
-func singlePassWithImage(ann *neuron.ANN, img image.Image, imageType int) float64 {
-	common.Debug("encode image")
-	ann.InputEncoding(img)
+	layerSize := len(samples[0].input)
 
-	common.Debug("forward propagate")
-	ann.ForwardPropagation()
+	initRng := common.NewRand(*seed, common.InitStream)
+	weights, err := common.CreateInitializer(*weight_init, initRng)
+	if err != nil {
+		common.PrintAndTerminate(err.Error())
+	}
 
-	common.Debug("output layer")
+	// the initializers replace the random func, which is only the fallback
+	ann := neuron.CreateANN(common.NormalDistributionHe(initRng), layerSize, *numberOfHiddenLayers,
+		neuron.WithHiddenActivations(parseActivations(*activations)...),
+		neuron.WithInitializers(weights, common.Constant(*biasInit)),
+		neuron.WithDropout(*dropoutRate, common.NewRand(*seed, common.DropoutStream)),
+		neuron.WithNormalization(createNormalization(*normalization)),
+		neuron.WithLabels(labels))
+	optimizer, err := neuron.CreateOptimizer(*optimizer_name)
+	if err != nil {
+		common.PrintAndTerminate(err.Error())
+	}
+	ann.Optimizer = optimizer
+	ann.Regularization = regularization
+	common.Log(fmt.Sprintf("regularization: l1 %g, l2 %g, clip value %g, clip norm %g",
+		regularization.L1, regularization.L2, regularization.ClipValue, regularization.ClipNorm))
+	scheduler := createScheduler(schedule)
 
-	expectedOneHotEncoding := make([]float64, 10) // 10 possible images
-	expectedOneHotEncoding[imageType] = 1         // onehot encoding value maps to imageType
+	layerSizes := ann.LayerSizes()
+	common.Log(fmt.Sprintf("created %d input layer neurons", layerSizes[0]))
+	for i, size := range layerSizes[1 : len(layerSizes)-1] {
+		common.Log(fmt.Sprintf("created %d hidden layer %d neurons", size, i+1))
+	}
+	common.Log(fmt.Sprintf("created %d output layer neurons", layerSizes[len(layerSizes)-1]))
 
-	sum := float64(0)
-	for i, node := range ann.OutputLayer {
-		scalar := node.Activation
-		sum += scalar
-		common.Debug(fmt.Sprintf("   %d: %f", i, scalar))
+	if augmentation != nil {
+		common.Log(fmt.Sprintf("augmentation: %s, each with probability %g", *augmentations, *augmentProbability))
 	}
-	common.Debug(fmt.Sprintf("sum (should equal 100): %f", sum))
 
-	loss := common.CrossEntropyLoss(expectedOneHotEncoding, outputToVector(ann.OutputLayer))
-	common.Debug(fmt.Sprintf("loss for image %d: %f", imageType, loss))
+	shuffleRng := common.NewRand(*seed, common.ShuffleStream)
+	augmentRng := common.NewRand(*seed, common.AugmentStream)
+	for epoch := 1; epoch <= *epochs; epoch++ {
+		shuffleRng.Shuffle(len(samples), func(i, j int) {
+			samples[i], samples[j] = samples[j], samples[i]
+		})
 
-	learningRate := .01
-	ann.BackwardPropagation(expectedOneHotEncoding, learningRate)
+		learningRate := scheduler.LearningRate(epoch - 1)
+		common.Log(fmt.Sprintf("epoch %d: learning rate %g", epoch, learningRate))
 
-	return loss
-}
+		loss := float64(0)
+		correct := 0
+		maxGradientNorm := float64(0)
+		for start := 0; start < len(samples); start += *batchSize {
+			batchSamples := samples[start:min(start+*batchSize, len(samples))]
+			if augmentation != nil {
+				batchSamples = augmentSamples(batchSamples, augmentation, augmentRng)
+			}
+			inputs, expected := batch(batchSamples)
+			batchLoss, batchCorrect := ann.TrainBatch(inputs, expected, learningRate)
+			loss += batchLoss
+			correct += batchCorrect
+			maxGradientNorm = max(maxGradientNorm, ann.GradientNorm())
+		}
+
+		common.Log(fmt.Sprintf("epoch %d: mean loss %f, accuracy %.2f%%",
+			epoch, loss/float64(len(samples)), 100*float64(correct)/float64(len(samples))))
+		common.Log(fmt.Sprintf("epoch %d: penalty %f, largest gradient norm %f", epoch, ann.Penalty(), maxGradientNorm))
 
-func outputToVector(neuron []*neuron.Neuron) []float64 {
-	vector := make([]float64, len(neuron))
+		observedLoss := loss / float64(len(samples)) // without a validation set schedules follow the training loss
+		if len(validationSamples) > 0 {
+			validationLoss, validationCorrect := evaluate(&ann, validationSamples, *batchSize)
+			observedLoss = validationLoss / float64(len(validationSamples))
+			common.Log(fmt.Sprintf("epoch %d: validation loss %f, validation accuracy %.2f%%",
+				epoch, observedLoss, 100*float64(validationCorrect)/float64(len(validationSamples))))
+		}
+		if observer, ok := scheduler.(neuron.LossObserver); ok {
+			observer.ObserveLoss(observedLoss)
+		}
+	}
 
-	for i := range len(neuron) {
-		vector[i] = neuron[i].Activation
+	if err := neuron.SaveFile(*model_file, &ann); err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not save model: %s %s", *model_file, err.Error()))
 	}
+	common.Log(fmt.Sprintf("saved model: %s", *model_file))
 
-	return vector
+	common.Log("done")
 }
@@ -1,53 +1,16 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"image"
-	"image/color"
 	"image/png"
 	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/dataset"
 	"os"
 	"path"
 )
 
-func translatePixels(original_img image.Image) image.Image {
-	bounds := original_img.Bounds()
-
-	// Create a new image with the same dimensions
-	newImg := image.NewRGBA(bounds)
-
-	// Define the threshold for "almost black"
-	// Pixels with average RGB value below this threshold will be black
-	// Adjust this value as needed (0-255)
-	threshold := 30
-
-	// Process each pixel
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			pixelColor := original_img.At(x, y)
-			r, g, b, _ := pixelColor.RGBA()
-
-			// Convert to 8-bit color values
-			r, g, b = r>>8, g>>8, b>>8
-
-			// Calculate average RGB value (ignoring alpha)
-			avgColor := (r + g + b) / 3
-
-			// Set new pixel color based on threshold
-			if avgColor < uint32(threshold) {
-				// Set to black
-				newImg.Set(x, y, color.RGBA{0, 0, 0, 255})
-			} else {
-				// Set to white
-				newImg.Set(x, y, color.RGBA{255, 255, 255, 255})
-			}
-		}
-	}
-
-	return newImg
-}
-
 func saveFile(dest_file_name string, translated_image image.Image) {
 	// Create the output file
 	outputFile, err := os.Create(dest_file_name)
@@ -64,40 +27,39 @@ func saveFile(dest_file_name string, translated_image image.Image) {
 
 func main() {
 	wd, _ := os.Getwd()
-	dataset_source_dir := path.Join(wd, "dataset")
-	dataset_dest_dir := path.Join(wd, "translated_dataset")
+	dataset_source_dir := flag.String("dataset", path.Join(wd, "dataset"), "directory with one sub directory of images per class")
+	dataset_dest_dir := flag.String("output", path.Join(wd, "translated_dataset"), "directory the translated images are written to")
+	flag.Parse()
 
-	for number := range []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9} {
-		number_dir := string(rune('0' + number))
-		source_dir := path.Join(dataset_source_dir, number_dir)
-		dest_dir := path.Join(dataset_dest_dir, number_dir)
-		dir_iterator, err := os.ReadDir(source_dir)
+	folder, err := dataset.OpenFolder(*dataset_source_dir)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not open dataset: %s", err.Error()))
+	}
 
+	for _, class := range folder.Classes {
+		dest_dir := path.Join(*dataset_dest_dir, class.Dir)
 		if err := os.MkdirAll(dest_dir, 0755); err != nil {
 			common.PrintAndTerminate(fmt.Sprintf("could not create output directory: %s", dest_dir))
 		}
+	}
 
+	for sample, err := range dataset.All(folder) {
 		if err != nil {
-			common.PrintAndTerminate("could not read dir")
+			common.PrintAndTerminate(err.Error())
 		}
 
-		for _, file_entry := range dir_iterator {
-			source_file_name := path.Join(source_dir, file_entry.Name())
-			dest_file_name := path.Join(dest_dir, file_entry.Name())
-			file_contents, err := os.ReadFile(source_file_name)
-			if err != nil {
-				common.PrintAndTerminate(fmt.Sprintf("could not read file: %s", source_file_name))
-			}
+		dest_file_name := path.Join(*dataset_dest_dir, folder.Classes[sample.Label].Dir, path.Base(sample.FilePath))
+		translated_image := common.Binarize(sample.Image)
 
-			img, err := png.Decode(bytes.NewReader(file_contents))
-			if err != nil {
-				common.PrintAndTerminate(fmt.Sprintf("could not read png: %s", source_file_name))
-			}
-
-			var _ string = dest_file_name
-			translated_image := translatePixels(img)
+		saveFile(dest_file_name, translated_image)
+	}
 
-			saveFile(dest_file_name, translated_image)
+	// the classes of the translated dataset are the same as the source's
+	manifest, err := os.ReadFile(path.Join(*dataset_source_dir, dataset.LabelManifest))
+	if err == nil {
+		dest_file_name := path.Join(*dataset_dest_dir, dataset.LabelManifest)
+		if err := os.WriteFile(dest_file_name, manifest, 0644); err != nil {
+			common.PrintAndTerminate(fmt.Sprintf("could not write file: %s", dest_file_name))
 		}
 	}
 }
@@ -1,12 +1,12 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
 	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/dataset"
 	"os"
 	"path"
 )
@@ -37,37 +37,25 @@ func verifyTwoColors(img image.Image) {
 
 func main() {
 	wd, _ := os.Getwd()
-	dataset_dir := path.Join(wd, "translated_dataset")
+	dataset_dir := flag.String("dataset", path.Join(wd, "translated_dataset"), "directory with one sub directory of images per class")
+	flag.Parse()
 
 	expected_width := 64
 	expected_height := 64
 
-	for number := range []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9} {
-
-		dir := path.Join(dataset_dir, string(rune('0'+number)))
-		dir_iterator, err := os.ReadDir(dir)
-
-		common.Log(fmt.Sprintf("checking dir: %s", dir))
+	folder, err := dataset.OpenFolder(*dataset_dir)
+	if err != nil {
+		common.PrintAndTerminate(fmt.Sprintf("could not open dataset: %s", err.Error()))
+	}
+	common.Log(fmt.Sprintf("checking %d images of %d classes in: %s", folder.Len(), len(folder.Classes), *dataset_dir))
 
+	for sample, err := range dataset.All(folder) {
 		if err != nil {
-			common.PrintAndTerminate("could not read dir")
+			common.PrintAndTerminate(err.Error())
 		}
 
-		for _, file_entry := range dir_iterator {
-			file_name := path.Join(dir, file_entry.Name())
-			file_contents, err := os.ReadFile(file_name)
-			if err != nil {
-				common.PrintAndTerminate(fmt.Sprintf("could not read file: %s", file_name))
-			}
-
-			img, err := png.Decode(bytes.NewReader(file_contents))
-			if err != nil {
-				common.PrintAndTerminate(fmt.Sprintf("could not read png: %s", file_name))
-			}
-
-			verifyImageResolution(img, expected_width, expected_height)
-			verifyTwoColors(img)
-		}
+		verifyImageResolution(sample.Image, expected_width, expected_height)
+		verifyTwoColors(sample.Image)
 	}
 
 	common.Log("all images have same resolution")
@@ -0,0 +1,187 @@
+package augment
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand/v2"
+	"slices"
+)
+
+var (
+	ink        = color.RGBA{0, 0, 0, 255}
+	background = color.RGBA{255, 255, 255, 255}
+)
+
+// Transform returns a changed copy of a binarized glyph image, black ink on
+// white, of the same size and with only those two colors. every random choice
+// is drawn from rng, so the same seed gives the same images
+type Transform func(img image.Image, rng *rand.Rand) image.Image
+
+// Pipeline applies the transforms one after the other
+func Pipeline(transforms ...Transform) Transform {
+	return func(img image.Image, rng *rand.Rand) image.Image {
+		for _, transform := range transforms {
+			img = transform(img, rng)
+		}
+		return img
+	}
+}
+
+// Maybe applies transform to a share probability of the images and returns
+// the others as they are
+func Maybe(probability float64, transform Transform) Transform {
+	return func(img image.Image, rng *rand.Rand) image.Image {
+		if rng.Float64() >= probability {
+			return img
+		}
+		return transform(img, rng)
+	}
+}
+
+// glyph is the ink of an image, one bool per pixel row by row
+type glyph struct {
+	bounds image.Rectangle
+	ink    []bool
+}
+
+func newGlyph(bounds image.Rectangle) *glyph {
+	return &glyph{bounds: bounds, ink: make([]bool, bounds.Dx()*bounds.Dy())}
+}
+
+func readGlyph(img image.Image) *glyph {
+	g := newGlyph(img.Bounds())
+	for y := range g.bounds.Dy() {
+		for x := range g.bounds.Dx() {
+			r, _, _, _ := img.At(g.bounds.Min.X+x, g.bounds.Min.Y+y).RGBA()
+			g.ink[y*g.bounds.Dx()+x] = r < 0x8000 // binarized images are black or white
+		}
+	}
+	return g
+}
+
+func (g *glyph) width() int  { return g.bounds.Dx() }
+func (g *glyph) height() int { return g.bounds.Dy() }
+
+// at is false outside of the glyph, which is background
+func (g *glyph) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= g.width() || y >= g.height() {
+		return false
+	}
+	return g.ink[y*g.width()+x]
+}
+
+func (g *glyph) set(x, y int, value bool) {
+	g.ink[y*g.width()+x] = value
+}
+
+func (g *glyph) inkCount() int {
+	count := 0
+	for _, value := range g.ink {
+		if value {
+			count++
+		}
+	}
+	return count
+}
+
+func (g *glyph) image() image.Image {
+	img := image.NewRGBA(g.bounds)
+	for y := range g.height() {
+		for x := range g.width() {
+			if g.at(x, y) {
+				img.SetRGBA(g.bounds.Min.X+x, g.bounds.Min.Y+y, ink)
+			} else {
+				img.SetRGBA(g.bounds.Min.X+x, g.bounds.Min.Y+y, background)
+			}
+		}
+	}
+	return img
+}
+
+// glyphTransform turns a change of the ink into a Transform. a change that
+// would leave no ink at all keeps the image as it was
+func glyphTransform(change func(g *glyph, rng *rand.Rand) *glyph) Transform {
+	return func(img image.Image, rng *rand.Rand) image.Image {
+		original := readGlyph(img)
+		changed := change(original, rng)
+		if changed.inkCount() == 0 && original.inkCount() > 0 {
+			return original.image()
+		}
+		return changed.image()
+	}
+}
+
+type transformConstructor struct {
+	defaults []float64 // options used when none are given
+	check    func(options []float64) error
+	create   func(options []float64) Transform
+}
+
+// largest distance in pixels any option can ask for, far beyond the 64x64 glyphs
+const maxPixels = 1024
+
+// transforms by name, used by CreateTransform
+var transformsByName = map[string]transformConstructor{
+	"translate": {defaults: []float64{4}, check: upTo(maxPixels), create: func(options []float64) Transform { return Translate(int(options[0])) }},
+	"rotate":    {defaults: []float64{10}, check: upTo(180), create: func(options []float64) Transform { return Rotate(options[0]) }},
+	"scale":     {defaults: []float64{.9, 1.1}, check: checkScale, create: func(options []float64) Transform { return Scale(options[0], options[1]) }},
+	"shear":     {defaults: []float64{.2}, check: upTo(maxPixels), create: func(options []float64) Transform { return Shear(options[0]) }},
+	"elastic":   {defaults: []float64{3, 6}, check: upTo(maxPixels), create: func(options []float64) Transform { return Elastic(options[0], options[1]) }},
+	"thicken":   {defaults: []float64{1}, check: upTo(maxPixels), create: func(options []float64) Transform { return Thicken(int(options[0])) }},
+	"thin":      {defaults: []float64{1}, check: upTo(maxPixels), create: func(options []float64) Transform { return Thin(int(options[0])) }},
+	"noise":     {defaults: []float64{.02}, check: upTo(1), create: func(options []float64) Transform { return SaltAndPepper(options[0]) }},
+	"occlude":   {defaults: []float64{16}, check: upTo(maxPixels), create: func(options []float64) Transform { return Occlude(int(options[0])) }},
+}
+
+// upTo accepts options between 0 and limit
+func upTo(limit float64) func(options []float64) error {
+	return func(options []float64) error {
+		for _, option := range options {
+			if !(option >= 0 && option <= limit) {
+				return fmt.Errorf("expects options between 0 and %g but has %g", limit, option)
+			}
+		}
+		return nil
+	}
+}
+
+// checkScale accepts factors above 0 with the smallest first
+func checkScale(options []float64) error {
+	minFactor, maxFactor := options[0], options[1]
+	if !(minFactor > 0 && minFactor <= maxFactor && maxFactor <= maxPixels) {
+		return fmt.Errorf("expects factors above 0 with the smallest first but has %g and %g", minFactor, maxFactor)
+	}
+	return nil
+}
+
+func TransformNames() []string {
+	names := []string{}
+	for name := range transformsByName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// CreateTransform creates the transform called name. options are the
+// arguments of its function, in the same order, and take a default value
+// when left out
+func CreateTransform(name string, options ...float64) (Transform, error) {
+	constructor, ok := transformsByName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown transform %q", name)
+	}
+
+	if len(options) == 0 {
+		options = constructor.defaults
+	}
+	if len(options) != len(constructor.defaults) {
+		return nil, fmt.Errorf("%s transform expects %d options but has %d", name, len(constructor.defaults), len(options))
+	}
+	if err := constructor.check(options); err != nil {
+		return nil, fmt.Errorf("%s transform %w", name, err)
+	}
+
+	return constructor.create(options), nil
+}
@@ -0,0 +1,279 @@
+package augment
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// testGlyph is a 64x64 image with a 4 pixel wide ring of ink, like a zero
+func testGlyph() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			dx, dy := x-32, y-32
+			if distance := dx*dx + dy*dy; distance >= 14*14 && distance < 18*18 {
+				img.SetRGBA(x, y, ink)
+			} else {
+				img.SetRGBA(x, y, background)
+			}
+		}
+	}
+	return img
+}
+
+func blankImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			img.SetRGBA(x, y, background)
+		}
+	}
+	return img
+}
+
+func inkPixels(img image.Image) int {
+	return readGlyph(img).inkCount()
+}
+
+func checkBinarized(t *testing.T, name string, img image.Image) {
+	t.Helper()
+	if img.Bounds() != image.Rect(0, 0, 64, 64) {
+		t.Fatalf("%s: expected 64x64 bounds but got %v", name, img.Bounds())
+	}
+	for y := range 64 {
+		for x := range 64 {
+			if c := color.RGBAModel.Convert(img.At(x, y)); c != ink && c != background {
+				t.Fatalf("%s: pixel (%d,%d) is %v, neither black nor white", name, x, y, c)
+			}
+		}
+	}
+}
+
+func sameImage(a, b image.Image) bool {
+	first, second := readGlyph(a), readGlyph(b)
+	if first.bounds != second.bounds {
+		return false
+	}
+	for i := range first.ink {
+		if first.ink[i] != second.ink[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTransformsKeepTheBinarizedFormatAndFollowTheSeed(t *testing.T) {
+	for _, name := range TransformNames() {
+		transform, err := CreateTransform(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for seed := range uint64(5) {
+			first := transform(testGlyph(), rand.New(rand.NewPCG(seed, 1)))
+			checkBinarized(t, name, first)
+			if inkPixels(first) == 0 {
+				t.Errorf("%s with seed %d erased the glyph", name, seed)
+			}
+
+			second := transform(testGlyph(), rand.New(rand.NewPCG(seed, 1)))
+			if !sameImage(first, second) {
+				t.Errorf("%s with seed %d gave two different images", name, seed)
+			}
+		}
+	}
+}
+
+func TestPipelineAppliesEveryTransformInOrder(t *testing.T) {
+	transforms := []Transform{}
+	for _, name := range TransformNames() {
+		transform, _ := CreateTransform(name)
+		transforms = append(transforms, transform)
+	}
+	pipeline := Pipeline(transforms...)
+
+	first := pipeline(testGlyph(), rand.New(rand.NewPCG(7, 1)))
+	checkBinarized(t, "pipeline", first)
+	if sameImage(first, testGlyph()) {
+		t.Errorf("expected the pipeline to change the glyph")
+	}
+	if !sameImage(first, pipeline(testGlyph(), rand.New(rand.NewPCG(7, 1)))) {
+		t.Errorf("expected the same seed to give the same image")
+	}
+	if sameImage(first, pipeline(testGlyph(), rand.New(rand.NewPCG(8, 1)))) {
+		t.Errorf("expected another seed to give another image")
+	}
+}
+
+func TestMaybeFollowsItsProbability(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	if !sameImage(Maybe(0, Thicken(1))(testGlyph(), rng), testGlyph()) {
+		t.Errorf("expected probability 0 to never transform")
+	}
+	if sameImage(Maybe(1, Thicken(1))(testGlyph(), rng), testGlyph()) {
+		t.Errorf("expected probability 1 to always transform")
+	}
+}
+
+func TestTranslateShiftsTheInk(t *testing.T) {
+	img := blankImage()
+	img.SetRGBA(30, 30, ink)
+
+	shifted := readGlyph(Translate(3)(img, rand.New(rand.NewPCG(2, 1))))
+
+	if shifted.inkCount() != 1 {
+		t.Fatalf("expected one ink pixel but got %d", shifted.inkCount())
+	}
+	for y := range 64 {
+		for x := range 64 {
+			if shifted.at(x, y) && (x < 27 || x > 33 || y < 27 || y > 33) {
+				t.Errorf("ink moved more than 3 pixels to (%d,%d)", x, y)
+			}
+		}
+	}
+}
+
+func TestGeometricTransformsWithoutRangeKeepTheGlyph(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	for name, transform := range map[string]Transform{
+		"translate": Translate(0),
+		"rotate":    Rotate(0),
+		"scale":     Scale(1, 1),
+		"shear":     Shear(0),
+		"elastic":   Elastic(0, 4),
+	} {
+		if !sameImage(transform(testGlyph(), rng), testGlyph()) {
+			t.Errorf("%s without range changed the glyph", name)
+		}
+	}
+}
+
+func TestRotateByHalfATurnMirrorsAroundTheCenter(t *testing.T) {
+	img := testGlyph().(*image.RGBA)
+	img.SetRGBA(10, 20, ink)
+
+	rotated := readGlyph(glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		return affine(g, -1, 0, 0, -1)
+	})(img, nil))
+
+	if !rotated.at(53, 43) {
+		t.Errorf("expected pixel (10,20) to end up at (53,43)")
+	}
+}
+
+func TestScaleUpGrowsTheInk(t *testing.T) {
+	scaled := Scale(1.5, 1.5)(testGlyph(), rand.New(rand.NewPCG(1, 1)))
+
+	if inkPixels(scaled) <= inkPixels(testGlyph()) {
+		t.Errorf("expected more ink after scaling up, got %d from %d", inkPixels(scaled), inkPixels(testGlyph()))
+	}
+}
+
+func TestThickenAndThinChangeTheStrokeWidth(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	original := inkPixels(testGlyph())
+
+	if thick := inkPixels(Thicken(1)(testGlyph(), rng)); thick <= original {
+		t.Errorf("expected thickening to add ink, got %d from %d", thick, original)
+	}
+	if thin := inkPixels(Thin(1)(testGlyph(), rng)); thin >= original || thin == 0 {
+		t.Errorf("expected thinning to remove some ink, got %d from %d", thin, original)
+	}
+}
+
+func TestThinKeepsGlyphsItWouldErase(t *testing.T) {
+	img := blankImage()
+	for x := 10; x < 50; x++ {
+		img.SetRGBA(x, 30, ink) // a stroke one pixel wide
+	}
+
+	if !sameImage(Thin(1)(img, rand.New(rand.NewPCG(1, 1))), img) {
+		t.Errorf("expected a glyph with 1 pixel strokes to stay as it is")
+	}
+}
+
+func TestZeroOptionsKeepTheGlyph(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	for name, transform := range map[string]Transform{
+		"thicken": Thicken(0),
+		"thin":    Thin(0),
+		"occlude": Occlude(0),
+	} {
+		if !sameImage(transform(testGlyph(), rng), testGlyph()) {
+			t.Errorf("%s: expected an option of 0 to keep the glyph", name)
+		}
+	}
+}
+
+func TestPixelTransformsAcceptAnEmptyImage(t *testing.T) {
+	empty := image.NewRGBA(image.Rect(0, 0, 0, 0))
+	if morphed := morph(readGlyph(empty), 1, true); len(morphed.ink) != 0 {
+		t.Errorf("expected morphing an empty glyph to stay empty, got %d pixels", len(morphed.ink))
+	}
+
+	rng := rand.New(rand.NewPCG(1, 1))
+	for name, transform := range map[string]Transform{
+		"thicken": Thicken(2),
+		"thin":    Thin(2),
+		"occlude": Occlude(16),
+	} {
+		if bounds := transform(empty, rng).Bounds(); !bounds.Empty() {
+			t.Errorf("%s: expected an empty image but got %v", name, bounds)
+		}
+	}
+}
+
+func TestSaltAndPepperFlipsAboutItsShareOfPixels(t *testing.T) {
+	noisy := readGlyph(SaltAndPepper(.1)(testGlyph(), rand.New(rand.NewPCG(1, 1))))
+	original := readGlyph(testGlyph())
+
+	flipped := 0
+	for i := range noisy.ink {
+		if noisy.ink[i] != original.ink[i] {
+			flipped++
+		}
+	}
+	if share := float64(flipped) / float64(len(noisy.ink)); share < .08 || share > .12 {
+		t.Errorf("expected about 10%% of the pixels flipped but got %.3f", share)
+	}
+}
+
+func TestOccludeOnlyRemovesInk(t *testing.T) {
+	occluded := readGlyph(Occlude(16)(testGlyph(), rand.New(rand.NewPCG(3, 1))))
+	original := readGlyph(testGlyph())
+
+	for i := range occluded.ink {
+		if occluded.ink[i] && !original.ink[i] {
+			t.Fatalf("occlusion added ink at pixel %d", i)
+		}
+	}
+}
+
+func TestCreateTransformChecksItsOptions(t *testing.T) {
+	if _, err := CreateTransform("blur"); err == nil {
+		t.Errorf("expected an error for an unknown transform")
+	}
+	if _, err := CreateTransform("scale", 1); err == nil {
+		t.Errorf("expected an error for a missing option")
+	}
+	for name, options := range map[string][]float64{
+		"rotate":    {-5},
+		"translate": {math.Inf(1)},
+		"noise":     {1.5},
+		"elastic":   {math.NaN(), 4},
+		"scale":     {0, 1.1},
+	} {
+		if _, err := CreateTransform(name, options...); err == nil {
+			t.Errorf("expected an error for %s with options %v", name, options)
+		}
+	}
+	if _, err := CreateTransform("scale", 1.2, .8); err == nil {
+		t.Errorf("expected an error for a scale range with the largest factor first")
+	}
+	if _, err := CreateTransform("rotate", 20); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+}
@@ -0,0 +1,145 @@
+package augment
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Translate shifts the glyph by up to maxShift pixels in both directions
+func Translate(maxShift int) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		dx, dy := rng.IntN(2*maxShift+1)-maxShift, rng.IntN(2*maxShift+1)-maxShift
+		shifted := newGlyph(g.bounds)
+		for y := range g.height() {
+			for x := range g.width() {
+				shifted.set(x, y, g.at(x-dx, y-dy))
+			}
+		}
+		return shifted
+	})
+}
+
+// Rotate turns the glyph around its center by up to maxDegrees either way
+func Rotate(maxDegrees float64) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		angle := uniform(rng, -maxDegrees, maxDegrees) * math.Pi / 180
+		sin, cos := math.Sincos(-angle) // every pixel looks up where it was turned from
+		return affine(g, cos, -sin, sin, cos)
+	})
+}
+
+// Scale resizes the glyph around its center by a factor between minFactor
+// and maxFactor, which must be above 0
+func Scale(minFactor, maxFactor float64) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		factor := uniform(rng, minFactor, maxFactor)
+		return affine(g, 1/factor, 0, 0, 1/factor)
+	})
+}
+
+// Shear slants the glyph horizontally by up to maxShear pixels per pixel of
+// height either way, as italic fonts do
+func Shear(maxShear float64) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		shear := uniform(rng, -maxShear, maxShear)
+		return affine(g, 1, -shear, 0, 1)
+	})
+}
+
+// Elastic bends the strokes by a random displacement field that is smoothed
+// with a gaussian of sigma pixels. alpha is the largest displacement in pixels
+func Elastic(alpha, sigma float64) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		dx := displacementField(g.width(), g.height(), alpha, sigma, rng)
+		dy := displacementField(g.width(), g.height(), alpha, sigma, rng)
+
+		bent := newGlyph(g.bounds)
+		for y := range g.height() {
+			for x := range g.width() {
+				i := y*g.width() + x
+				bent.set(x, y, g.at(int(math.Floor(float64(x)+.5+dx[i])), int(math.Floor(float64(y)+.5+dy[i]))))
+			}
+		}
+		return bent
+	})
+}
+
+func uniform(rng *rand.Rand, low, high float64) float64 {
+	return low + rng.Float64()*(high-low)
+}
+
+// affine samples every pixel of the result from the pixel of g that the
+// matrix [a b; c d] maps it to, relative to the center, so that the glyph
+// stays binary
+func affine(g *glyph, a, b, c, d float64) *glyph {
+	centerX, centerY := float64(g.width())/2, float64(g.height())/2
+	transformed := newGlyph(g.bounds)
+	for y := range g.height() {
+		for x := range g.width() {
+			px, py := float64(x)+.5-centerX, float64(y)+.5-centerY
+			sourceX := math.Floor(a*px + b*py + centerX)
+			sourceY := math.Floor(c*px + d*py + centerY)
+			transformed.set(x, y, g.at(int(sourceX), int(sourceY)))
+		}
+	}
+	return transformed
+}
+
+// displacementField is uniform noise blurred with a gaussian and scaled so
+// that its largest value is alpha
+func displacementField(width, height int, alpha, sigma float64, rng *rand.Rand) []float64 {
+	field := make([]float64, width*height)
+	for i := range field {
+		field[i] = uniform(rng, -1, 1)
+	}
+
+	kernel := gaussianKernel(sigma)
+	radius := len(kernel) / 2
+	blurred := make([]float64, len(field))
+	for y := range height { // the gaussian is separable, rows first
+		for x := range width {
+			sum := float64(0)
+			for k, weight := range kernel {
+				sum += weight * field[y*width+min(max(x+k-radius, 0), width-1)]
+			}
+			blurred[y*width+x] = sum
+		}
+	}
+	largest := float64(0)
+	for y := range height {
+		for x := range width {
+			sum := float64(0)
+			for k, weight := range kernel {
+				sum += weight * blurred[min(max(y+k-radius, 0), height-1)*width+x]
+			}
+			field[y*width+x] = sum
+			largest = max(largest, math.Abs(sum))
+		}
+	}
+
+	if largest > 0 {
+		for i := range field {
+			field[i] *= alpha / largest
+		}
+	}
+	return field
+}
+
+func gaussianKernel(sigma float64) []float64 {
+	if sigma <= 0 {
+		return []float64{1}
+	}
+
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := float64(0)
+	for i := range kernel {
+		distance := float64(i - radius)
+		kernel[i] = math.Exp(-distance * distance / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
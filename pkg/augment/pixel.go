@@ -0,0 +1,79 @@
+package augment
+
+import "math/rand/v2"
+
+// Thicken dilates the strokes by between 1 and maxRadius pixels, a
+// maxRadius of 0 keeps them as they are
+func Thicken(maxRadius int) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		if maxRadius < 1 {
+			return g
+		}
+		return morph(g, 1+rng.IntN(maxRadius), true)
+	})
+}
+
+// Thin erodes the strokes by between 1 and maxRadius pixels. a glyph whose
+// strokes would disappear entirely, or a maxRadius of 0, keeps them as they are
+func Thin(maxRadius int) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		if maxRadius < 1 {
+			return g
+		}
+		return morph(g, 1+rng.IntN(maxRadius), false)
+	})
+}
+
+// SaltAndPepper flips every pixel with the given probability
+func SaltAndPepper(probability float64) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		noisy := newGlyph(g.bounds)
+		for i, value := range g.ink {
+			noisy.ink[i] = value != (rng.Float64() < probability)
+		}
+		return noisy
+	})
+}
+
+// Occlude covers a random rectangle of up to maxSize by maxSize pixels with
+// background, hiding a part of the strokes. a maxSize of 0 or an empty image
+// is left as it is
+func Occlude(maxSize int) Transform {
+	return glyphTransform(func(g *glyph, rng *rand.Rand) *glyph {
+		if maxSize < 1 || g.width() == 0 || g.height() == 0 {
+			return g
+		}
+		width := 1 + rng.IntN(min(maxSize, g.width()))
+		height := 1 + rng.IntN(min(maxSize, g.height()))
+		left, top := rng.IntN(g.width()-width+1), rng.IntN(g.height()-height+1)
+
+		occluded := newGlyph(g.bounds)
+		copy(occluded.ink, g.ink)
+		for y := top; y < top+height; y++ {
+			for x := left; x < left+width; x++ {
+				occluded.set(x, y, false)
+			}
+		}
+		return occluded
+	})
+}
+
+// morph dilates the ink with a square of radius pixels, or erodes it when
+// dilate is false
+func morph(g *glyph, radius int, dilate bool) *glyph {
+	morphed := newGlyph(g.bounds)
+	for y := range g.height() {
+		for x := range g.width() {
+			value := !dilate
+			for dy := -radius; dy <= radius && value != dilate; dy++ {
+				for dx := -radius; dx <= radius && value != dilate; dx++ {
+					if g.at(x+dx, y+dy) == dilate {
+						value = dilate
+					}
+				}
+			}
+			morphed.set(x, y, value)
+		}
+	}
+	return morphed
+}
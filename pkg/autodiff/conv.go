@@ -0,0 +1,113 @@
+package autodiff
+
+import (
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+// Convolution describes images laid out as channel x height x width and the
+// kernels slid over them
+type Convolution struct {
+	Channels   int
+	Height     int
+	Width      int
+	KernelSize int
+	Stride     int
+	Padding    int
+}
+
+func (conv Convolution) OutputHeight() int {
+	return (conv.Height+2*conv.Padding-conv.KernelSize)/conv.Stride + 1
+}
+
+func (conv Convolution) OutputWidth() int {
+	return (conv.Width+2*conv.Padding-conv.KernelSize)/conv.Stride + 1
+}
+
+// Conv2D convolves every row of a batch x (channel x height x width) input
+// with kernels of shape output channel x (channel x kernel row x kernel
+// column) and adds one bias per output channel. rows of the result are laid
+// out as output channel x output row x output column
+func Conv2D(input, kernels, biases *Variable, conv Convolution) *Variable {
+	inputSize := conv.Channels * conv.Height * conv.Width
+	patchSize := conv.Channels * conv.KernelSize * conv.KernelSize
+	if len(input.Value.Shape) != 2 || input.Value.Shape[1] != inputSize {
+		common.PrintAndTerminate(fmt.Sprintf("input of shape %v does not match convolution over %d values", input.Value.Shape, inputSize))
+	}
+	if len(kernels.Value.Shape) != 2 || kernels.Value.Shape[1] != patchSize || biases.Value.Len() != kernels.Value.Shape[0] {
+		common.PrintAndTerminate(fmt.Sprintf("kernels of shape %v and biases of shape %v do not match patches of %d values", kernels.Value.Shape, biases.Value.Shape, patchSize))
+	}
+	if conv.KernelSize <= 0 || conv.Stride <= 0 || conv.Padding < 0 || conv.OutputHeight() <= 0 || conv.OutputWidth() <= 0 {
+		common.PrintAndTerminate(fmt.Sprintf("invalid convolution %+v", conv))
+	}
+
+	batchSize, outputChannels := input.Value.Shape[0], kernels.Value.Shape[0]
+	outputArea := conv.OutputHeight() * conv.OutputWidth()
+	patchIdxs := conv.patchIndexes()
+
+	value := tensor.New(batchSize, outputChannels*outputArea)
+	columns := make([]*tensor.Tensor, batchSize)
+	for n := range batchSize {
+		columns[n] = tensor.New(outputArea, patchSize)
+		row := input.Value.Row(n)
+		for i, inputIdx := range patchIdxs {
+			if inputIdx >= 0 {
+				columns[n].Data[i] = row[inputIdx]
+			}
+		}
+
+		sample := tensor.MatMul(kernels.Value, columns[n].Transpose())
+		output := value.Row(n)
+		for i, x := range sample.Data {
+			output[i] = x + biases.Value.Data[i/outputArea]
+		}
+	}
+
+	return sameTape(input, kernels, biases).record(value, func(result *Variable) {
+		for n := range batchSize {
+			gradient := tensor.FromSlice(result.Gradient.Row(n), outputChannels, outputArea)
+
+			kernels.Gradient.Add(tensor.MatMul(gradient, columns[n]))
+			for i, x := range gradient.Data {
+				biases.Gradient.Data[i/outputArea] += x
+			}
+
+			columnGradient := tensor.MatMul(gradient.Transpose(), kernels.Value)
+			inputGradient := input.Gradient.Row(n)
+			for i, inputIdx := range patchIdxs {
+				if inputIdx >= 0 {
+					inputGradient[inputIdx] += columnGradient.Data[i]
+				}
+			}
+		}
+	})
+}
+
+// maps every (output position, kernel position) pair to the input it reads.
+// positions that fall in the zero padding map to -1
+func (conv Convolution) patchIndexes() []int {
+	outputHeight, outputWidth := conv.OutputHeight(), conv.OutputWidth()
+	patchIdxs := make([]int, 0, outputHeight*outputWidth*conv.Channels*conv.KernelSize*conv.KernelSize)
+
+	for oy := range outputHeight {
+		for ox := range outputWidth {
+			for ic := range conv.Channels {
+				for ky := range conv.KernelSize {
+					for kx := range conv.KernelSize {
+						y := oy*conv.Stride + ky - conv.Padding
+						x := ox*conv.Stride + kx - conv.Padding
+
+						if y < 0 || y >= conv.Height || x < 0 || x >= conv.Width {
+							patchIdxs = append(patchIdxs, -1)
+						} else {
+							patchIdxs = append(patchIdxs, (ic*conv.Height+y)*conv.Width+x)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return patchIdxs
+}
@@ -0,0 +1,165 @@
+package autodiff
+
+import (
+	"fmt"
+	"math"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+func Add(a, b *Variable) *Variable {
+	checkSameShape(a, b, "add")
+	value := a.Value.Clone()
+	value.Add(b.Value)
+
+	return sameTape(a, b).record(value, func(result *Variable) {
+		a.Gradient.Add(result.Gradient)
+		b.Gradient.Add(result.Gradient)
+	})
+}
+
+func Sub(a, b *Variable) *Variable {
+	return Add(a, Scale(b, -1))
+}
+
+// Mul multiplies element by element
+func Mul(a, b *Variable) *Variable {
+	checkSameShape(a, b, "multiply")
+	value := tensor.New(a.Value.Shape...)
+	for i := range value.Data {
+		value.Data[i] = a.Value.Data[i] * b.Value.Data[i]
+	}
+
+	return sameTape(a, b).record(value, func(result *Variable) {
+		for i, gradient := range result.Gradient.Data {
+			a.Gradient.Data[i] += gradient * b.Value.Data[i]
+			b.Gradient.Data[i] += gradient * a.Value.Data[i]
+		}
+	})
+}
+
+func Scale(a *Variable, factor float64) *Variable {
+	return elementwise(a, func(x float64) float64 { return factor * x }, func(x, y float64) float64 { return factor })
+}
+
+// AddBias adds bias to every row of a batch x features variable
+func AddBias(a, bias *Variable) *Variable {
+	if len(a.Value.Shape) != 2 || bias.Value.Len() != a.Value.Shape[1] {
+		common.PrintAndTerminate(fmt.Sprintf("cannot add bias of shape %v to rows of shape %v", bias.Value.Shape, a.Value.Shape))
+	}
+
+	value := a.Value.Clone()
+	for n := range value.Shape[0] {
+		row := value.Row(n)
+		for j, b := range bias.Value.Data {
+			row[j] += b
+		}
+	}
+
+	return sameTape(a, bias).record(value, func(result *Variable) {
+		a.Gradient.Add(result.Gradient)
+		for n := range result.Gradient.Shape[0] {
+			for j, gradient := range result.Gradient.Row(n) {
+				bias.Gradient.Data[j] += gradient
+			}
+		}
+	})
+}
+
+func MatMul(a, b *Variable) *Variable {
+	value := tensor.MatMul(a.Value, b.Value)
+
+	return sameTape(a, b).record(value, func(result *Variable) {
+		a.Gradient.Add(tensor.MatMul(result.Gradient, b.Value.Transpose()))
+		b.Gradient.Add(tensor.MatMul(a.Value.Transpose(), result.Gradient))
+	})
+}
+
+// Transpose swaps the rows and columns of a 2 dimensional variable
+func Transpose(a *Variable) *Variable {
+	value := a.Value.Transpose().Clone()
+
+	return a.tape.record(value, func(result *Variable) {
+		a.Gradient.Add(result.Gradient.Transpose().Clone())
+	})
+}
+
+func ReLU(a *Variable) *Variable {
+	return elementwise(a, common.ReLU, func(x, y float64) float64 { return common.ReLUDerivative(x) })
+}
+
+func Sigmoid(a *Variable) *Variable {
+	return elementwise(a, common.Sigmoid, func(x, y float64) float64 { return y * (1 - y) })
+}
+
+func Tanh(a *Variable) *Variable {
+	return elementwise(a, math.Tanh, func(x, y float64) float64 { return 1 - y*y })
+}
+
+func Exp(a *Variable) *Variable {
+	return elementwise(a, math.Exp, func(x, y float64) float64 { return y })
+}
+
+// Log is the natural logarithm
+func Log(a *Variable) *Variable {
+	return elementwise(a, math.Log, func(x, y float64) float64 { return 1 / x })
+}
+
+// elementwise applies function to every value. derivative gets both the
+// input x and the output y, whichever is cheaper to use
+func elementwise(a *Variable, function func(float64) float64, derivative func(x, y float64) float64) *Variable {
+	value := tensor.New(a.Value.Shape...)
+	for i, x := range a.Value.Data {
+		value.Data[i] = function(x)
+	}
+
+	return a.tape.record(value, func(result *Variable) {
+		for i, gradient := range result.Gradient.Data {
+			a.Gradient.Data[i] += gradient * derivative(a.Value.Data[i], value.Data[i])
+		}
+	})
+}
+
+// Softmax normalizes every row of a batch x features variable into probabilities
+func Softmax(a *Variable) *Variable {
+	if len(a.Value.Shape) != 2 {
+		common.PrintAndTerminate(fmt.Sprintf("cannot take the softmax of shape %v", a.Value.Shape))
+	}
+
+	value := tensor.New(a.Value.Shape...)
+	for n := range value.Shape[0] {
+		copy(value.Row(n), common.SoftMax(a.Value.Row(n)))
+	}
+
+	return a.tape.record(value, func(result *Variable) {
+		for n := range value.Shape[0] {
+			probabilities, gradient, inputGradient := value.Row(n), result.Gradient.Row(n), a.Gradient.Row(n)
+
+			dot := float64(0)
+			for j, probability := range probabilities {
+				dot += probability * gradient[j]
+			}
+			for j, probability := range probabilities {
+				inputGradient[j] += probability * (gradient[j] - dot)
+			}
+		}
+	})
+}
+
+// Sum adds up every value into a single valued variable
+func Sum(a *Variable) *Variable {
+	sum := float64(0)
+	for _, x := range a.Value.Data {
+		sum += x
+	}
+
+	return a.tape.record(tensor.FromSlice([]float64{sum}, 1), func(result *Variable) {
+		for i := range a.Gradient.Data {
+			a.Gradient.Data[i] += result.Gradient.Data[0]
+		}
+	})
+}
+
+func Mean(a *Variable) *Variable {
+	return Scale(Sum(a), 1/float64(a.Value.Len()))
+}
@@ -0,0 +1,140 @@
+package autodiff
+
+import (
+	"math"
+	"math/rand/v2"
+	"ocr_cnn/pkg/tensor"
+	"testing"
+)
+
+func randomTensor(rng *rand.Rand, shape ...int) *tensor.Tensor {
+	t := tensor.New(shape...)
+	for i := range t.Data {
+		t.Data[i] = rng.NormFloat64()
+	}
+	return t
+}
+
+// compares the gradients of every input against central finite differences
+// of a random weighted sum of the output
+func checkGradients(t *testing.T, rng *rand.Rand, build func(inputs []*Variable) *Variable, values ...*tensor.Tensor) {
+	t.Helper()
+
+	record := func() (*Tape, []*Variable, *Variable) {
+		tape := CreateTape()
+		inputs := make([]*Variable, len(values))
+		for i, value := range values {
+			inputs[i] = tape.Variable(value)
+		}
+		return tape, inputs, build(inputs)
+	}
+
+	tape, inputs, output := record()
+	outputWeights := randomTensor(rng, output.Value.Shape...)
+	tape.BackwardFrom(output, outputWeights)
+
+	loss := func() float64 {
+		_, _, output := record()
+		sum := float64(0)
+		for i, value := range output.Value.Data {
+			sum += value * outputWeights.Data[i]
+		}
+		return sum
+	}
+
+	const epsilon = 1e-6
+	for v, value := range values {
+		for i := range value.Data {
+			original := value.Data[i]
+			value.Data[i] = original + epsilon
+			lossPlus := loss()
+			value.Data[i] = original - epsilon
+			lossMinus := loss()
+			value.Data[i] = original
+
+			expected := (lossPlus - lossMinus) / (2 * epsilon)
+			actual := inputs[v].Gradient.Data[i]
+			if math.Abs(expected-actual) > 1e-6*math.Max(1, math.Abs(expected)) {
+				t.Errorf("input %d value %d: expected gradient %e but got %e", v, i, expected, actual)
+			}
+		}
+	}
+}
+
+func TestOperationsMatchFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	positive := randomTensor(rng, 2, 3)
+	for i := range positive.Data {
+		positive.Data[i] = math.Abs(positive.Data[i]) + .5
+	}
+
+	for name, test := range map[string]struct {
+		build  func(inputs []*Variable) *Variable
+		values []*tensor.Tensor
+	}{
+		"add":       {func(x []*Variable) *Variable { return Add(x[0], x[1]) }, []*tensor.Tensor{randomTensor(rng, 2, 3), randomTensor(rng, 2, 3)}},
+		"sub":       {func(x []*Variable) *Variable { return Sub(x[0], x[1]) }, []*tensor.Tensor{randomTensor(rng, 2, 3), randomTensor(rng, 2, 3)}},
+		"mul":       {func(x []*Variable) *Variable { return Mul(x[0], x[1]) }, []*tensor.Tensor{randomTensor(rng, 2, 3), randomTensor(rng, 2, 3)}},
+		"scale":     {func(x []*Variable) *Variable { return Scale(x[0], -1.5) }, []*tensor.Tensor{randomTensor(rng, 2, 3)}},
+		"add bias":  {func(x []*Variable) *Variable { return AddBias(x[0], x[1]) }, []*tensor.Tensor{randomTensor(rng, 2, 3), randomTensor(rng, 3)}},
+		"matmul":    {func(x []*Variable) *Variable { return MatMul(x[0], x[1]) }, []*tensor.Tensor{randomTensor(rng, 2, 3), randomTensor(rng, 3, 4)}},
+		"transpose": {func(x []*Variable) *Variable { return Transpose(x[0]) }, []*tensor.Tensor{randomTensor(rng, 2, 3)}},
+		"relu":      {func(x []*Variable) *Variable { return ReLU(x[0]) }, []*tensor.Tensor{randomTensor(rng, 2, 3)}},
+		"sigmoid":   {func(x []*Variable) *Variable { return Sigmoid(x[0]) }, []*tensor.Tensor{randomTensor(rng, 2, 3)}},
+		"tanh":      {func(x []*Variable) *Variable { return Tanh(x[0]) }, []*tensor.Tensor{randomTensor(rng, 2, 3)}},
+		"exp":       {func(x []*Variable) *Variable { return Exp(x[0]) }, []*tensor.Tensor{randomTensor(rng, 2, 3)}},
+		"log":       {func(x []*Variable) *Variable { return Log(x[0]) }, []*tensor.Tensor{positive}},
+		"softmax":   {func(x []*Variable) *Variable { return Softmax(x[0]) }, []*tensor.Tensor{randomTensor(rng, 2, 4)}},
+		"sum":       {func(x []*Variable) *Variable { return Sum(x[0]) }, []*tensor.Tensor{randomTensor(rng, 2, 3)}},
+		"mean":      {func(x []*Variable) *Variable { return Mean(x[0]) }, []*tensor.Tensor{randomTensor(rng, 2, 3)}},
+		"conv2d": {func(x []*Variable) *Variable {
+			return Conv2D(x[0], x[1], x[2], Convolution{Channels: 2, Height: 5, Width: 4, KernelSize: 3, Stride: 2, Padding: 1})
+		}, []*tensor.Tensor{randomTensor(rng, 2, 2*5*4), randomTensor(rng, 3, 2*3*3), randomTensor(rng, 3)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			checkGradients(t, rng, test.build, test.values...)
+		})
+	}
+}
+
+func TestCompositionsMatchFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	targets := tensor.FromSlice([]float64{
+		0, 1, 0,
+		1, 0, 0,
+	}, 2, 3)
+
+	// cross entropy of a two layer network, with the input reused by a skip connection
+	checkGradients(t, rng, func(x []*Variable) *Variable {
+		input, hiddenWeights, outputWeights, biases := x[0], x[1], x[2], x[3]
+		hidden := Add(Tanh(MatMul(input, hiddenWeights)), input)
+		probabilities := Softmax(AddBias(MatMul(hidden, outputWeights), biases))
+		return Scale(Sum(Mul(x[0].tape.Variable(targets), Log(probabilities))), -1)
+	}, randomTensor(rng, 2, 4), randomTensor(rng, 4, 4), randomTensor(rng, 4, 3), randomTensor(rng, 3))
+}
+
+func TestConv2DOutput(t *testing.T) {
+	tape := CreateTape()
+	input := tape.Variable(tensor.FromSlice([]float64{
+		1, 2, 3,
+		4, 5, 6,
+		7, 8, 9,
+	}, 1, 9))
+	kernels := tape.Variable(tensor.FromSlice([]float64{.1, .2, .3, .4}, 1, 4))
+	biases := tape.Variable(tensor.FromSlice([]float64{.5}, 1))
+
+	output := Conv2D(input, kernels, biases, Convolution{Channels: 1, Height: 3, Width: 3, KernelSize: 2, Stride: 1})
+
+	expected := []float64{
+		(.1*1 + .2*2 + .3*4 + .4*5) + .5,
+		(.1*2 + .2*3 + .3*5 + .4*6) + .5,
+		(.1*4 + .2*5 + .3*7 + .4*8) + .5,
+		(.1*5 + .2*6 + .3*8 + .4*9) + .5,
+	}
+	for i := range expected {
+		if math.Abs(output.Value.Data[i]-expected[i]) > 1e-12 {
+			t.Errorf("output %d: expected %f but got %f", i, expected[i], output.Value.Data[i])
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package autodiff records tensor operations on a tape and computes the
+// gradients of any composition of them in one reverse pass
+package autodiff
+
+import (
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+)
+
+// Tape keeps every Variable in the order it was computed, so walking it
+// backwards visits each one only after everything that was computed from it
+type Tape struct {
+	variables []*Variable
+}
+
+// Variable is a value on a tape together with the gradient accumulated for it
+// by Backward
+type Variable struct {
+	Value    *tensor.Tensor
+	Gradient *tensor.Tensor
+
+	tape     *Tape
+	backward func() // adds the gradient of this variable into its inputs, nil for leaves
+}
+
+func CreateTape() *Tape {
+	return &Tape{}
+}
+
+// Variable records a leaf with a fresh gradient. non contiguous values are copied
+func (tape *Tape) Variable(value *tensor.Tensor) *Variable {
+	if !value.IsContiguous() {
+		value = value.Clone()
+	}
+	return tape.Parameter(value, tensor.New(value.Shape...))
+}
+
+// Parameter records a leaf whose gradients are added to gradient, which lets
+// a model keep accumulating into its own tensors across tapes
+func (tape *Tape) Parameter(value, gradient *tensor.Tensor) *Variable {
+	if value.Len() != gradient.Len() || !value.IsContiguous() || !gradient.IsContiguous() {
+		common.PrintAndTerminate(fmt.Sprintf("gradient of shape %v does not fit value of shape %v", gradient.Shape, value.Shape))
+	}
+
+	variable := &Variable{Value: value, Gradient: gradient, tape: tape}
+	tape.variables = append(tape.variables, variable)
+	return variable
+}
+
+// record adds the result of an operation. backward is called with the
+// variable once its gradient is complete
+func (tape *Tape) record(value *tensor.Tensor, backward func(result *Variable)) *Variable {
+	variable := &Variable{Value: value, Gradient: tensor.New(value.Shape...), tape: tape}
+	variable.backward = func() { backward(variable) }
+	tape.variables = append(tape.variables, variable)
+	return variable
+}
+
+// Backward differentiates a single valued output w.r.t every variable it was
+// computed from. a tape is walked once; record a new one for the next pass
+func (tape *Tape) Backward(output *Variable) {
+	if output.Value.Len() != 1 {
+		common.PrintAndTerminate(fmt.Sprintf("cannot differentiate output of shape %v, use BackwardFrom", output.Value.Shape))
+	}
+
+	seed := tensor.New(output.Value.Shape...)
+	seed.Fill(1)
+	tape.BackwardFrom(output, seed)
+}
+
+// BackwardFrom starts the reverse pass with the gradient of some loss w.r.t output
+func (tape *Tape) BackwardFrom(output *Variable, outputGradient *tensor.Tensor) {
+	if output.tape != tape {
+		common.PrintAndTerminate("output was recorded on another tape")
+	}
+	output.Gradient.Add(outputGradient)
+
+	last := len(tape.variables) - 1
+	for last >= 0 && tape.variables[last] != output {
+		last--
+	}
+
+	for i := last; i >= 0; i-- {
+		if variable := tape.variables[i]; variable.backward != nil {
+			variable.backward()
+		}
+	}
+}
+
+func sameTape(variables ...*Variable) *Tape {
+	tape := variables[0].tape
+	for _, variable := range variables[1:] {
+		if variable.tape != tape {
+			common.PrintAndTerminate("variables were recorded on different tapes")
+		}
+	}
+	return tape
+}
+
+func checkSameShape(a, b *Variable, operation string) {
+	if !slices.Equal(a.Value.Shape, b.Value.Shape) {
+		common.PrintAndTerminate(fmt.Sprintf("cannot %s shapes %v and %v", operation, a.Value.Shape, b.Value.Shape))
+	}
+}
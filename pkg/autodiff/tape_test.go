@@ -0,0 +1,51 @@
+package autodiff
+
+import (
+	"ocr_cnn/pkg/tensor"
+	"slices"
+	"testing"
+)
+
+func TestBackwardAddsUpEveryUseOfAVariable(t *testing.T) {
+	tape := CreateTape()
+	x := tape.Variable(tensor.FromSlice([]float64{1, 2, 3}, 3))
+
+	// sum(x * x + x) has gradient 2x + 1
+	tape.Backward(Sum(Add(Mul(x, x), x)))
+
+	if !slices.Equal(x.Gradient.Data, []float64{3, 5, 7}) {
+		t.Errorf("expected [3 5 7] but got %v", x.Gradient.Data)
+	}
+}
+
+func TestParameterAccumulatesIntoItsGradient(t *testing.T) {
+	value := tensor.FromSlice([]float64{1, 2}, 2)
+	gradient := tensor.FromSlice([]float64{10, 10}, 2)
+
+	for range 2 {
+		tape := CreateTape()
+		tape.Backward(Sum(Scale(tape.Parameter(value, gradient), 3)))
+	}
+
+	if !slices.Equal(gradient.Data, []float64{16, 16}) {
+		t.Errorf("expected [16 16] but got %v", gradient.Data)
+	}
+}
+
+func TestBackwardFromOnlyReachesTheVariablesOutputCameFrom(t *testing.T) {
+	tape := CreateTape()
+	x := tape.Variable(tensor.FromSlice([]float64{1, 2}, 1, 2))
+	y := tape.Variable(tensor.FromSlice([]float64{3, 4}, 1, 2))
+
+	output := Scale(x, 2)
+	Sum(Mul(output, y)) // recorded later and never differentiated
+
+	tape.BackwardFrom(output, tensor.FromSlice([]float64{1, -1}, 1, 2))
+
+	if !slices.Equal(x.Gradient.Data, []float64{2, -2}) {
+		t.Errorf("expected [2 -2] but got %v", x.Gradient.Data)
+	}
+	if !slices.Equal(y.Gradient.Data, []float64{0, 0}) {
+		t.Errorf("expected y to get no gradient but got %v", y.Gradient.Data)
+	}
+}
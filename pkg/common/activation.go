@@ -0,0 +1,58 @@
+package common
+
+import "math"
+
+func LeakyReLU(x, slope float64) float64 {
+	if x > 0 {
+		return x
+	}
+	return slope * x
+}
+
+func LeakyReLUDerivative(x, slope float64) float64 {
+	if x > 0 {
+		return 1
+	}
+	return slope
+}
+
+func ELU(x, alpha float64) float64 {
+	if x > 0 {
+		return x
+	}
+	return alpha * (math.Exp(x) - 1)
+}
+
+func ELUDerivative(x, alpha float64) float64 {
+	if x > 0 {
+		return 1
+	}
+	return alpha * math.Exp(x)
+}
+
+// GELU weights x by the probability that a standard normal value is below it
+func GELU(x float64) float64 {
+	return x * normalCDF(x)
+}
+
+func GELUDerivative(x float64) float64 {
+	return normalCDF(x) + x*math.Exp(-x*x/2)/math.Sqrt(2*math.Pi)
+}
+
+func normalCDF(x float64) float64 {
+	return (1 + math.Erf(x/math.Sqrt2)) / 2
+}
+
+func Sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+func SigmoidDerivative(x float64) float64 {
+	sigmoid := Sigmoid(x)
+	return sigmoid * (1 - sigmoid)
+}
+
+func TanhDerivative(x float64) float64 {
+	tanh := math.Tanh(x)
+	return 1 - tanh*tanh
+}
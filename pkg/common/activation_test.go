@@ -0,0 +1,48 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestActivationValues(t *testing.T) {
+	cases := []struct {
+		name     string
+		actual   float64
+		expected float64
+	}{
+		{"leaky relu of a negative", LeakyReLU(-2, .1), -.2},
+		{"leaky relu of a positive", LeakyReLU(3, .1), 3},
+		{"elu of a negative", ELU(-1, 2), 2 * (math.Exp(-1) - 1)},
+		{"gelu of 0", GELU(0), 0},
+		{"gelu of a large input", GELU(10), 10},
+		{"sigmoid of 0", Sigmoid(0), .5},
+	}
+
+	for _, c := range cases {
+		if math.Abs(c.actual-c.expected) > 1e-12 {
+			t.Errorf("%s: expected %f but got %f", c.name, c.expected, c.actual)
+		}
+	}
+}
+
+func TestActivationDerivativesMatchFiniteDifferences(t *testing.T) {
+	activations := map[string][2]func(float64) float64{
+		"leaky relu": {func(x float64) float64 { return LeakyReLU(x, .1) }, func(x float64) float64 { return LeakyReLUDerivative(x, .1) }},
+		"elu":        {func(x float64) float64 { return ELU(x, 1.5) }, func(x float64) float64 { return ELUDerivative(x, 1.5) }},
+		"gelu":       {GELU, GELUDerivative},
+		"sigmoid":    {Sigmoid, SigmoidDerivative},
+		"tanh":       {math.Tanh, TanhDerivative},
+	}
+
+	const epsilon = 1e-6
+	for name, activation := range activations {
+		function, derivative := activation[0], activation[1]
+		for _, x := range []float64{-2.5, -.3, .4, 1.7} {
+			expected := (function(x+epsilon) - function(x-epsilon)) / (2 * epsilon)
+			if math.Abs(derivative(x)-expected) > 1e-6 {
+				t.Errorf("%s at %f: expected derivative %f but got %f", name, x, expected, derivative(x))
+			}
+		}
+	}
+}
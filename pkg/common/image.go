@@ -0,0 +1,63 @@
+package common
+
+import (
+	"image"
+	"image/color"
+)
+
+// Pixels with average RGB value below this threshold will be black
+// Adjust this value as needed (0-255)
+const BinarizeThreshold = 30
+
+// Binarize converts an image to only be black and white
+func Binarize(original_img image.Image) image.Image {
+	bounds := original_img.Bounds()
+
+	// Create a new image with the same dimensions
+	newImg := image.NewRGBA(bounds)
+
+	// Process each pixel
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixelColor := original_img.At(x, y)
+			r, g, b, _ := pixelColor.RGBA()
+
+			// Convert to 8-bit color values
+			r, g, b = r>>8, g>>8, b>>8
+
+			// Calculate average RGB value (ignoring alpha)
+			avgColor := (r + g + b) / 3
+
+			// Set new pixel color based on threshold
+			if avgColor < uint32(BinarizeThreshold) {
+				// Set to black
+				newImg.Set(x, y, color.RGBA{0, 0, 0, 255})
+			} else {
+				// Set to white
+				newImg.Set(x, y, color.RGBA{255, 255, 255, 255})
+			}
+		}
+	}
+
+	return newImg
+}
+
+// Resize scales an image to width x height with nearest neighbour sampling,
+// which keeps a binarized image to only two colors. the result starts at (0, 0)
+func Resize(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Min.X == 0 && bounds.Min.Y == 0 && bounds.Dx() == width && bounds.Dy() == height {
+		return img
+	}
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			sourceX := bounds.Min.X + x*bounds.Dx()/width
+			sourceY := bounds.Min.Y + y*bounds.Dy()/height
+			newImg.Set(x, y, img.At(sourceX, sourceY))
+		}
+	}
+
+	return newImg
+}
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBinarizeOnlyKeepsNearlyBlackPixels(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.Set(0, 0, color.RGBA{10, 20, 30, 255})
+	img.Set(1, 0, color.RGBA{40, 40, 40, 255})
+	img.Set(2, 0, color.RGBA{200, 0, 0, 255})
+
+	binarized := Binarize(img)
+
+	expected := []color.RGBA{
+		{0, 0, 0, 255},
+		{255, 255, 255, 255},
+		{255, 255, 255, 255},
+	}
+	for x, expectedColor := range expected {
+		if actual := color.RGBAModel.Convert(binarized.At(x, 0)); actual != expectedColor {
+			t.Errorf("pixel %d: expected %v but got %v", x, expectedColor, actual)
+		}
+	}
+}
+
+func TestResizeUsesNearestNeighbour(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, black)
+	img.Set(1, 0, white)
+	img.Set(0, 1, white)
+	img.Set(1, 1, black)
+
+	resized := Resize(img, 4, 4)
+
+	if resized.Bounds() != image.Rect(0, 0, 4, 4) {
+		t.Fatalf("expected 4x4 bounds but got %v", resized.Bounds())
+	}
+	for y := range 4 {
+		for x := range 4 {
+			expected := img.At(x/2, y/2)
+			if color.RGBAModel.Convert(resized.At(x, y)) != expected {
+				t.Errorf("pixel (%d,%d): expected %v but got %v", x, y, expected, resized.At(x, y))
+			}
+		}
+	}
+}
+
+func TestResizeKeepsImagesThatAlreadyFit(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	if Resize(img, 4, 4) != image.Image(img) {
+		t.Errorf("expected image of the right size to be returned as is")
+	}
+}
@@ -0,0 +1,163 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// Initializer fills the parameters of a layer. fanIn is the number of inputs
+// of every unit and fanOut the number of units every input reaches, values
+// holds len(values)/fanIn rows of fanIn weights
+type Initializer func(values []float64, fanIn, fanOut int)
+
+// FromRandomFunc adapts a function that is given the fan in, such as
+// NormalDistributionHe, into an Initializer
+func FromRandomFunc(randomFunc func(int) float64) Initializer {
+	return func(values []float64, fanIn, _ int) {
+		for i := range values {
+			values[i] = randomFunc(fanIn)
+		}
+	}
+}
+
+func Constant(value float64) Initializer {
+	return func(values []float64, _, _ int) {
+		for i := range values {
+			values[i] = value
+		}
+	}
+}
+
+func normal(rng *rand.Rand, stdDev func(fanIn, fanOut int) float64) Initializer {
+	return func(values []float64, fanIn, fanOut int) {
+		scale := stdDev(fanIn, fanOut)
+		for i := range values {
+			values[i] = scale * rng.NormFloat64()
+		}
+	}
+}
+
+func uniform(rng *rand.Rand, limit func(fanIn, fanOut int) float64) Initializer {
+	return func(values []float64, fanIn, fanOut int) {
+		scale := limit(fanIn, fanOut)
+		for i := range values {
+			values[i] = scale * (2*rng.Float64() - 1)
+		}
+	}
+}
+
+// XavierNormal keeps the variance of activations and gradients alike for
+// tanh and sigmoid layers
+func XavierNormal(rng *rand.Rand) Initializer {
+	return normal(rng, func(fanIn, fanOut int) float64 { return math.Sqrt(2 / float64(fanIn+fanOut)) })
+}
+
+func XavierUniform(rng *rand.Rand) Initializer {
+	return uniform(rng, func(fanIn, fanOut int) float64 { return math.Sqrt(6 / float64(fanIn+fanOut)) })
+}
+
+func LeCunNormal(rng *rand.Rand) Initializer {
+	return normal(rng, func(fanIn, _ int) float64 { return math.Sqrt(1 / float64(fanIn)) })
+}
+
+// HeNormal is NormalDistributionHe drawing from rng, suited to ReLU layers
+func HeNormal(rng *rand.Rand) Initializer {
+	return normal(rng, func(fanIn, _ int) float64 { return math.Sqrt(2 / float64(fanIn)) })
+}
+
+func HeUniform(rng *rand.Rand) Initializer {
+	return uniform(rng, func(fanIn, _ int) float64 { return math.Sqrt(6 / float64(fanIn)) })
+}
+
+// TruncatedNormal draws again every value further than 2 standard deviations from the mean
+func TruncatedNormal(rng *rand.Rand, mean, stdDev float64) Initializer {
+	return func(values []float64, _, _ int) {
+		for i := range values {
+			z := rng.NormFloat64()
+			for math.Abs(z) > 2 {
+				z = rng.NormFloat64()
+			}
+			values[i] = mean + stdDev*z
+		}
+	}
+}
+
+// Orthogonal makes the rows of the weights orthonormal, or the columns when
+// there are more rows than columns, and multiplies them by gain
+func Orthogonal(rng *rand.Rand, gain float64) Initializer {
+	return func(values []float64, fanIn, _ int) {
+		rows, columns := len(values)/fanIn, fanIn
+
+		// orthonormalize the shorter side, stored as vectors of the longer one
+		count, size := rows, columns
+		if rows > columns {
+			count, size = columns, rows
+		}
+
+		vectors := make([][]float64, count)
+		for v := range vectors {
+			vectors[v] = make([]float64, size)
+			for i := range vectors[v] {
+				vectors[v][i] = rng.NormFloat64()
+			}
+			for _, previous := range vectors[:v] { // modified Gram-Schmidt
+				dot := float64(0)
+				for i := range previous {
+					dot += previous[i] * vectors[v][i]
+				}
+				for i := range previous {
+					vectors[v][i] -= dot * previous[i]
+				}
+			}
+			norm := float64(0)
+			for _, value := range vectors[v] {
+				norm += value * value
+			}
+			norm = math.Sqrt(norm)
+			for i := range vectors[v] {
+				vectors[v][i] /= norm
+			}
+		}
+
+		for r := range rows {
+			for c := range columns {
+				if rows > columns {
+					values[r*columns+c] = gain * vectors[c][r]
+				} else {
+					values[r*columns+c] = gain * vectors[r][c]
+				}
+			}
+		}
+	}
+}
+
+// initializers by name with their usual settings
+var initializersByName = map[string]func(rng *rand.Rand) Initializer{
+	"he_normal":        HeNormal,
+	"he_uniform":       HeUniform,
+	"xavier_normal":    XavierNormal,
+	"xavier_uniform":   XavierUniform,
+	"lecun_normal":     LeCunNormal,
+	"orthogonal":       func(rng *rand.Rand) Initializer { return Orthogonal(rng, 1) },
+	"truncated_normal": func(rng *rand.Rand) Initializer { return TruncatedNormal(rng, 0, .05) },
+	"zeros":            func(*rand.Rand) Initializer { return Constant(0) },
+}
+
+func InitializerNames() []string {
+	names := []string{}
+	for name := range initializersByName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func CreateInitializer(name string, rng *rand.Rand) (Initializer, error) {
+	createInitializer, ok := initializersByName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown initializer %q", name)
+	}
+	return createInitializer(rng), nil
+}
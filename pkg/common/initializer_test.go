@@ -0,0 +1,120 @@
+package common
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func variance(values []float64) float64 {
+	mean, sum := float64(0), float64(0)
+	for _, value := range values {
+		mean += value / float64(len(values))
+	}
+	for _, value := range values {
+		sum += (value - mean) * (value - mean)
+	}
+	return sum / float64(len(values))
+}
+
+func TestInitializersScaleByFan(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	const fanIn, fanOut = 40, 60
+
+	expectedVariances := map[string]struct {
+		initializer Initializer
+		variance    float64
+	}{
+		"xavier normal":  {XavierNormal(rng), 2.0 / (fanIn + fanOut)},
+		"xavier uniform": {XavierUniform(rng), 2.0 / (fanIn + fanOut)},
+		"lecun normal":   {LeCunNormal(rng), 1.0 / fanIn},
+		"he normal":      {HeNormal(rng), 2.0 / fanIn},
+		"he uniform":     {HeUniform(rng), 2.0 / fanIn},
+	}
+
+	for name, expected := range expectedVariances {
+		values := make([]float64, 20000)
+		expected.initializer(values, fanIn, fanOut)
+
+		if actual := variance(values); math.Abs(actual-expected.variance) > .05*expected.variance {
+			t.Errorf("%s: expected variance %f but got %f", name, expected.variance, actual)
+		}
+	}
+}
+
+func TestTruncatedNormalStaysWithinTwoStandardDeviations(t *testing.T) {
+	values := make([]float64, 10000)
+	TruncatedNormal(rand.New(rand.NewPCG(3, 4)), 1, .5)(values, 1, 1)
+
+	for _, value := range values {
+		if value < 0 || value > 2 {
+			t.Fatalf("expected values within [0, 2] but got %f", value)
+		}
+	}
+}
+
+func TestOrthogonal(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+
+	for _, shape := range [][2]int{{3, 5}, {5, 3}} {
+		rows, columns := shape[0], shape[1]
+		values := make([]float64, rows*columns)
+		Orthogonal(rng, 2)(values, columns, rows)
+
+		// the shorter side has orthogonal vectors of length gain
+		count, size := rows, columns
+		at := func(v, i int) float64 { return values[v*columns+i] }
+		if rows > columns {
+			count, size = columns, rows
+			at = func(v, i int) float64 { return values[i*columns+v] }
+		}
+
+		for a := range count {
+			for b := range count {
+				dot := float64(0)
+				for i := range size {
+					dot += at(a, i) * at(b, i)
+				}
+				expected := float64(0)
+				if a == b {
+					expected = 4
+				}
+				if math.Abs(dot-expected) > 1e-9 {
+					t.Errorf("%dx%d: expected dot product of %d and %d to be %f but got %f", rows, columns, a, b, expected, dot)
+				}
+			}
+		}
+	}
+}
+
+func TestInitializersAreReproducible(t *testing.T) {
+	for _, name := range InitializerNames() {
+		first, second := make([]float64, 12), make([]float64, 12)
+
+		initializer, err := CreateInitializer(name, rand.New(rand.NewPCG(7, 8)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		initializer(first, 4, 3)
+		initializer, _ = CreateInitializer(name, rand.New(rand.NewPCG(7, 8)))
+		initializer(second, 4, 3)
+
+		if !slices.Equal(first, second) {
+			t.Errorf("%s: expected the same seed to give the same values", name)
+		}
+	}
+
+	if _, err := CreateInitializer("ones", nil); err == nil {
+		t.Errorf("expected an error for an unknown initializer")
+	}
+}
+
+func TestFromRandomFuncPassesTheFanIn(t *testing.T) {
+	values := make([]float64, 3)
+	FromRandomFunc(func(fanIn int) float64 { return float64(fanIn) })(values, 7, 2)
+
+	if !slices.Equal(values, []float64{7, 7, 7}) {
+		t.Errorf("expected [7 7 7] but got %v", values)
+	}
+}
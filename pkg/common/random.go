@@ -0,0 +1,19 @@
+package common
+
+import "math/rand/v2"
+
+// every part of the pipeline that draws random numbers has its own stream of
+// the seed, so drawing more numbers in one part does not change the others
+const (
+	SplitStream uint64 = iota + 1
+	InitStream
+	ShuffleStream
+	DropoutStream
+	AugmentStream
+)
+
+// NewRand returns the random numbers of one stream of seed. the same seed and
+// stream always give the same numbers
+func NewRand(seed, stream uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, stream))
+}
@@ -1,14 +1,10 @@
 package common
 
 import (
-	"bytes"
 	"fmt"
-	"image"
-	"image/png"
 	"math"
 	"math/rand/v2"
 	"os"
-	"path"
 )
 
 func Log(message string) {
@@ -24,19 +20,19 @@ func PrintAndTerminate(message string) {
 	os.Exit(1)
 }
 
-func RandomUniformDistrbutionFunc(min, max float64) func(int) float64 {
+func RandomUniformDistrbutionFunc(rng *rand.Rand, min, max float64) func(int) float64 {
 	return func(_ int) float64 {
-		return min + (max-min)*rand.Float64()
+		return min + (max-min)*rng.Float64()
 	}
 }
 
-func NormalDistributionHe() func(int) float64 {
+func NormalDistributionHe(rng *rand.Rand) func(int) float64 {
 	return func(n int) float64 {
 		if n <= 0 {
 			PrintAndTerminate(fmt.Sprintf("invalid n vaue for NormalHe: %d", n))
 		}
 		stdDev := math.Sqrt(2.0 / float64(n))
-		return stdDev * rand.NormFloat64()
+		return stdDev * rng.NormFloat64()
 	}
 }
 
@@ -44,6 +40,13 @@ func ReLU(x float64) float64 {
 	return math.Max(float64(x), float64(0))
 }
 
+func ReLUDerivative(x float64) float64 {
+	if x > 0 {
+		return 1
+	}
+	return 0
+}
+
 func CrossEntropyLoss(trueProababilities []float64, predictedProbabilities []float64) float64 {
 	sum := float64(0)
 
@@ -123,28 +126,13 @@ func CrossEntropyPartialDerivative(softmaxOutput, target []float64) []float64 {
 	return grad
 }
 
-func GetImage(number string, idx int) image.Image {
-	wd, _ := os.Getwd()
-	dataset_dir := path.Join(wd, "translated_dataset")
-
-	dir := path.Join(dataset_dir, number)
-	dir_iterator, err := os.ReadDir(dir)
-
-	if err != nil {
-		PrintAndTerminate("could not read dir")
-	}
-
-	file_name := path.Join(dir, dir_iterator[idx].Name())
-	file_contents, err := os.ReadFile(file_name)
-
-	if err != nil {
-		PrintAndTerminate(fmt.Sprintf("could not read file: %s", file_name))
-	}
-
-	img, err := png.Decode(bytes.NewReader(file_contents))
-	if err != nil {
-		PrintAndTerminate(fmt.Sprintf("could not read png: %s", file_name))
+// Argmax is the index of the largest value, the first one on ties
+func Argmax(vector []float64) int {
+	best := 0
+	for i, value := range vector {
+		if value > vector[best] {
+			best = i
+		}
 	}
-
-	return img
+	return best
 }
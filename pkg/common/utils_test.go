@@ -53,3 +53,41 @@ func TestSoftmaxCrossEntroypGradient(t *testing.T) {
 		t.Errorf("expected gradient vector to be %v but was %v", expectedGradientVector, actualGradientVector)
 	}
 }
+
+func TestReLUDerivative(t *testing.T) {
+	for _, tc := range []struct{ x, expected float64 }{
+		{-1, 0},
+		{0, 0},
+		{.5, 1},
+	} {
+		if actual := ReLUDerivative(tc.x); actual != tc.expected {
+			t.Errorf("expected derivative at %f to be %f but was %f", tc.x, tc.expected, actual)
+		}
+	}
+}
+
+func TestArgmax(t *testing.T) {
+	if actual := Argmax([]float64{.1, .5, .2, .5}); actual != 1 {
+		t.Errorf("expected index 1 but was %d", actual)
+	}
+}
+
+func TestRandomFuncsDrawFromTheGivenRand(t *testing.T) {
+	for name, create := range map[string]func(uint64) func(int) float64{
+		"normal he": func(seed uint64) func(int) float64 { return NormalDistributionHe(NewRand(seed, InitStream)) },
+		"uniform": func(seed uint64) func(int) float64 {
+			return RandomUniformDistrbutionFunc(NewRand(seed, InitStream), -1, 1)
+		},
+	} {
+		first, second, other := create(1), create(1), create(2)
+		for i := range 5 {
+			a, b, c := first(4), second(4), other(4)
+			if a != b {
+				t.Errorf("%s %d: expected the same seed to give the same value but got %f and %f", name, i, a, b)
+			}
+			if a == c {
+				t.Errorf("%s %d: expected different seeds to give different values", name, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package dataset reads labeled images. loading errors are returned to the
+// caller instead of terminating the program
+package dataset
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"iter"
+	"os"
+)
+
+// Sample is one image of a dataset together with what is known about it
+type Sample struct {
+	Image    image.Image
+	Label    int // index into the Labels of the dataset
+	FilePath string
+	Font     string // font family the image was rendered in
+}
+
+// Dataset is an indexed collection of samples
+type Dataset interface {
+	Len() int
+	Labels() []string
+	Get(i int) (Sample, error)
+}
+
+// Listed is a dataset that knows the file and label of every sample without
+// loading it, which is all SplitEntries needs. Close releases what it
+// holds open, and with it every subset
+type Listed interface {
+	Dataset
+	List() []Entry
+	Subset(entries []Entry) Dataset
+	Close() error
+}
+
+// Open reads the IDX files idxImages and idxLabels when they are given, with
+// the class names of manifest if that is given too, and otherwise path, which
+// is either a folder per class or a file written by WritePacked
+func Open(path, idxImages, idxLabels, manifest string, transpose bool) (Listed, error) {
+	if idxImages == "" && idxLabels == "" {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return OpenPacked(path)
+		}
+		return OpenFolder(path)
+	}
+
+	idx, err := OpenIDX(idxImages, idxLabels, manifest)
+	if err != nil {
+		return nil, err
+	}
+	idx.Transpose = transpose
+	return idx, nil
+}
+
+// All yields every sample in order. after an error nothing more is yielded
+func All(dataset Dataset) iter.Seq2[Sample, error] {
+	return func(yield func(Sample, error) bool) {
+		for i := range dataset.Len() {
+			sample, err := dataset.Get(i)
+			if !yield(sample, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Batches yields the samples in order, batchSize at a time. the last batch
+// holds what is left. after an error nothing more is yielded
+func Batches(dataset Dataset, batchSize int) iter.Seq2[[]Sample, error] {
+	return func(yield func([]Sample, error) bool) {
+		if batchSize <= 0 {
+			yield(nil, fmt.Errorf("invalid batch size %d", batchSize))
+			return
+		}
+
+		for start := 0; start < dataset.Len(); start += batchSize {
+			batch := make([]Sample, 0, min(batchSize, dataset.Len()-start))
+			for i := start; i < start+cap(batch); i++ {
+				sample, err := dataset.Get(i)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				batch = append(batch, sample)
+			}
+
+			if !yield(batch, nil) {
+				return
+			}
+		}
+	}
+}
+
+// LoadImage decodes a png file
+func LoadImage(file_name string) (image.Image, error) {
+	file_contents, err := os.ReadFile(file_name)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(file_contents))
+	if err != nil {
+		return nil, fmt.Errorf("could not read png: %s %w", file_name, err)
+	}
+
+	return img, nil
+}
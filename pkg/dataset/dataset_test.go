@@ -0,0 +1,94 @@
+package dataset
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+func TestBatchesHoldEverySampleOnce(t *testing.T) {
+	dataset_dir := t.TempDir()
+	createImages(t, dataset_dir, "0", "A_0.png", "B_0.png", "C_0.png")
+	createImages(t, dataset_dir, "1", "A_1.png", "B_1.png")
+
+	folder, err := OpenFolder(dataset_dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := []int{}
+	seen := map[string]bool{}
+	for batch, err := range Batches(folder, 2) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(batch))
+		for _, sample := range batch {
+			seen[sample.FilePath] = true
+		}
+	}
+
+	if len(sizes) != 3 || sizes[2] != 1 || len(seen) != 5 {
+		t.Errorf("expected batches of 2, 2 and 1 covering 5 samples but got %v covering %d", sizes, len(seen))
+	}
+}
+
+func TestAllStopsAtTheFirstError(t *testing.T) {
+	dataset_dir := t.TempDir()
+	createImages(t, dataset_dir, "0", "A_0.png", "B_0.png", "C_0.png")
+	if err := os.WriteFile(path.Join(dataset_dir, "0", "B_0.png"), []byte("not a png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	folder, err := OpenFolder(dataset_dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, errors := 0, 0
+	for _, err := range All(folder) {
+		if err != nil {
+			errors++
+		} else {
+			loaded++
+		}
+	}
+
+	if loaded != 1 || errors != 1 {
+		t.Errorf("expected 1 sample and then 1 error but got %d samples and %d errors", loaded, errors)
+	}
+}
+
+func TestOpenPicksTheFormatOfItsArguments(t *testing.T) {
+	folder, file_name := packFolder(t)
+
+	if opened, err := Open(folder.Root, "", "", "", false); err != nil {
+		t.Error(err)
+	} else if _, ok := opened.(*Folder); !ok {
+		t.Errorf("expected a directory to open as a folder but got %T", opened)
+	}
+
+	if opened, err := Open(file_name, "", "", "", false); err != nil {
+		t.Error(err)
+	} else {
+		defer opened.Close()
+		if _, ok := opened.(*Packed); !ok {
+			t.Errorf("expected a file to open as a packed dataset but got %T", opened)
+		}
+	}
+
+	images, labels, err := ExportIDX(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images_file, labels_file := writeIDXFiles(t, images, labels)
+	if opened, err := Open(folder.Root, images_file, labels_file, "", true); err != nil {
+		t.Error(err)
+	} else if idx, ok := opened.(*IDX); !ok || !idx.Transpose {
+		t.Errorf("expected the IDX files to open transposed but got %T", opened)
+	}
+
+	if _, err := Open(path.Join(t.TempDir(), "missing"), "", "", "", false); err == nil {
+		t.Errorf("expected an error for a missing dataset")
+	}
+}
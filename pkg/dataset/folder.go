@@ -0,0 +1,167 @@
+package dataset
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// LabelManifest is the file of a dataset directory that lists its classes
+const LabelManifest = "labels.txt"
+
+// Class is a directory of images and the label of the character they show
+type Class struct {
+	Dir   string
+	Label string
+}
+
+// Folder is a dataset with one directory of images per class under Root
+type Folder struct {
+	Root    string
+	Classes []Class
+	Entries []Entry // Label is an index into Classes
+}
+
+// OpenFolder lists the classes and images under root. when root has a
+// LabelManifest the classes are read from it in order, otherwise every sub
+// directory is a class labeled by its name, in sorted order
+func OpenFolder(root string) (*Folder, error) {
+	classes, err := listClasses(root)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &Folder{Root: root, Classes: classes, Entries: []Entry{}}
+	for label, class := range classes {
+		dir := path.Join(root, class.Dir)
+		dir_iterator, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("could not read class %q: %w", class.Label, err)
+		}
+
+		for _, file_entry := range dir_iterator {
+			if !file_entry.IsDir() {
+				folder.Entries = append(folder.Entries, Entry{
+					FilePath: path.Join(dir, file_entry.Name()),
+					Label:    label,
+				})
+			}
+		}
+	}
+
+	return folder, nil
+}
+
+func listClasses(root string) ([]Class, error) {
+	manifest, err := os.Open(path.Join(root, LabelManifest))
+	if err == nil {
+		defer manifest.Close()
+
+		classes, err := ReadLabelManifest(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("could not read label manifest of %s: %w", root, err)
+		}
+		return classes, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	dir_iterator, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := []Class{}
+	for _, dir_entry := range dir_iterator {
+		if dir_entry.IsDir() {
+			classes = append(classes, Class{Dir: dir_entry.Name(), Label: dir_entry.Name()})
+		}
+	}
+	return classes, nil
+}
+
+// ReadLabelManifest reads one class per line: the directory of its images,
+// optionally followed by its label for characters such as / that cannot be
+// directory names. empty lines are skipped
+func ReadLabelManifest(reader io.Reader) ([]Class, error) {
+	classes := []Class{}
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+
+		var class Class
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			class = Class{Dir: fields[0], Label: fields[0]}
+		case 2:
+			class = Class{Dir: fields[0], Label: fields[1]}
+		default:
+			return nil, fmt.Errorf("line %d: expected a directory and an optional label but got %q", line, scanner.Text())
+		}
+
+		if seen[class.Label] {
+			return nil, fmt.Errorf("line %d: label %q is listed twice", line, class.Label)
+		}
+		seen[class.Label] = true
+		classes = append(classes, class)
+	}
+
+	return classes, scanner.Err()
+}
+
+// Subset is a folder of the given entries, such as one part of a
+// SplitEntries, with the same classes
+func (folder *Folder) Subset(entries []Entry) Dataset {
+	return &Folder{Root: folder.Root, Classes: folder.Classes, Entries: entries}
+}
+
+// Close does nothing, every image is read when it is needed
+func (folder *Folder) Close() error {
+	return nil
+}
+
+func (folder *Folder) List() []Entry {
+	return folder.Entries
+}
+
+func (folder *Folder) Len() int {
+	return len(folder.Entries)
+}
+
+// Labels is the label of every class, in order
+func (folder *Folder) Labels() []string {
+	labels := make([]string, len(folder.Classes))
+	for i, class := range folder.Classes {
+		labels[i] = class.Label
+	}
+	return labels
+}
+
+func (folder *Folder) Get(i int) (Sample, error) {
+	if i < 0 || i >= len(folder.Entries) {
+		return Sample{}, fmt.Errorf("sample %d out of range for %d samples", i, len(folder.Entries))
+	}
+
+	entry := folder.Entries[i]
+	img, err := LoadImage(entry.FilePath)
+	if err != nil {
+		return Sample{}, err
+	}
+
+	return Sample{
+		Image:    img,
+		Label:    entry.Label,
+		FilePath: entry.FilePath,
+		Font:     FontFamily(entry.FilePath),
+	}, nil
+}
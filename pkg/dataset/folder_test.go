@@ -0,0 +1,117 @@
+package dataset
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writes a small gray png for every file name under dataset_dir/dir
+func createImages(t *testing.T, dataset_dir, dir string, file_names ...string) {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	img.SetGray(1, 0, color.Gray{Y: 255})
+	img.SetGray(0, 1, color.Gray{Y: 128})
+
+	buffer := bytes.Buffer{}
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(path.Join(dataset_dir, dir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file_name := range file_names {
+		if err := os.WriteFile(path.Join(dataset_dir, dir, file_name), buffer.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOpenFolderLabelsEveryImageByDirectory(t *testing.T) {
+	dataset_dir := t.TempDir()
+	createImages(t, dataset_dir, "b")
+	createImages(t, dataset_dir, "A", "Abadi_0.png", "Arial_1.png")
+	createImages(t, dataset_dir, "7")
+	if err := os.WriteFile(path.Join(dataset_dir, "README.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	folder, err := OpenFolder(dataset_dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if labels := folder.Labels(); !slices.Equal(labels, []string{"7", "A", "b"}) {
+		t.Errorf("expected [7 A b] but got %v", labels)
+	}
+	if folder.Len() != 2 {
+		t.Fatalf("expected 2 samples but got %d", folder.Len())
+	}
+
+	sample, err := folder.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.Label != 1 || sample.Font != "Arial" || sample.Image.Bounds().Dx() != 2 {
+		t.Errorf("expected a 2 pixel wide Arial A but got label %d font %s width %d", sample.Label, sample.Font, sample.Image.Bounds().Dx())
+	}
+}
+
+func TestOpenFolderFollowsTheLabelManifest(t *testing.T) {
+	dataset_dir := t.TempDir()
+	createImages(t, dataset_dir, "a", "Arial_0.png")
+	createImages(t, dataset_dir, "slash", "Arial_0.png")
+	createImages(t, dataset_dir, "0", "Arial_0.png")
+	manifest := "a\n\nslash /\n0\n"
+	if err := os.WriteFile(path.Join(dataset_dir, LabelManifest), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	folder, err := OpenFolder(dataset_dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if labels := folder.Labels(); !slices.Equal(labels, []string{"a", "/", "0"}) {
+		t.Errorf("expected [a / 0] but got %v", labels)
+	}
+	for _, entry := range folder.Entries {
+		expected := slices.Index([]string{"a", "slash", "0"}, path.Base(path.Dir(entry.FilePath)))
+		if entry.Label != expected {
+			t.Errorf("expected %s to be labeled %d but was %d", entry.FilePath, expected, entry.Label)
+		}
+	}
+}
+
+func TestOpenFolderReturnsErrors(t *testing.T) {
+	if _, err := OpenFolder(path.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("expected an error for a missing root")
+	}
+
+	dataset_dir := t.TempDir()
+	if err := os.WriteFile(path.Join(dataset_dir, LabelManifest), []byte("missing\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFolder(dataset_dir); err == nil {
+		t.Errorf("expected an error for a class without a directory")
+	}
+}
+
+func TestReadLabelManifestRejectsBadLines(t *testing.T) {
+	for name, manifest := range map[string]string{
+		"too many fields": "a b c\n",
+		"repeated label":  "a\nb a\n",
+	} {
+		if _, err := ReadLabelManifest(strings.NewReader(manifest)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// the only IDX value type read and written: unsigned bytes
+const idxUnsignedByte = 0x08
+
+// upper bound for the values of an IDX file, so a corrupt header cannot ask
+// for huge allocations. the largest EMNIST split is about a quarter of it
+const maxIDXSize = 1 << 31
+
+// IDXArray is the contents of an IDX file, the format MNIST and EMNIST ship
+// in: unsigned bytes laid out as Dims, last dimension fastest
+type IDXArray struct {
+	Dims []int
+	Data []byte
+}
+
+// ReadIDX reads a plain or gzipped IDX file of unsigned bytes
+func ReadIDX(reader io.Reader) (IDXArray, error) {
+	buffered := bufio.NewReader(reader)
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		unzipped, err := gzip.NewReader(buffered)
+		if err != nil {
+			return IDXArray{}, err
+		}
+		defer unzipped.Close()
+		buffered = bufio.NewReader(unzipped)
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(buffered, header); err != nil {
+		return IDXArray{}, fmt.Errorf("could not read IDX header: %w", err)
+	}
+	if header[0] != 0 || header[1] != 0 {
+		return IDXArray{}, fmt.Errorf("not an IDX file")
+	}
+	if header[2] != idxUnsignedByte {
+		return IDXArray{}, fmt.Errorf("unsupported IDX value type %#x", header[2])
+	}
+
+	array := IDXArray{Dims: make([]int, header[3])}
+	size := 1
+	for i := range array.Dims {
+		var dim uint32
+		if err := binary.Read(buffered, binary.BigEndian, &dim); err != nil {
+			return IDXArray{}, fmt.Errorf("could not read IDX dimensions: %w", err)
+		}
+		array.Dims[i] = int(dim)
+		if dim > 0 && size > maxIDXSize/int(dim) {
+			return IDXArray{}, fmt.Errorf("IDX dimensions %v are too large", array.Dims[:i+1])
+		}
+		size *= int(dim)
+	}
+
+	// the buffer grows with the data actually read, not with what the header claims
+	data := bytes.NewBuffer(make([]byte, 0, min(size, 1<<20)))
+	if _, err := io.CopyN(data, buffered, int64(size)); err != nil {
+		return IDXArray{}, fmt.Errorf("IDX data is shorter than dimensions %v: %w", array.Dims, err)
+	}
+	array.Data = data.Bytes()
+
+	return array, nil
+}
+
+func WriteIDX(writer io.Writer, array IDXArray) error {
+	size := 1
+	for _, dim := range array.Dims {
+		size *= dim
+	}
+	if size != len(array.Data) || len(array.Dims) > 255 {
+		return fmt.Errorf("%d values do not fit IDX dimensions %v", len(array.Data), array.Dims)
+	}
+
+	header := []byte{0, 0, idxUnsignedByte, byte(len(array.Dims))}
+	for _, dim := range array.Dims {
+		header = binary.BigEndian.AppendUint32(header, uint32(dim))
+	}
+
+	if _, err := writer.Write(header); err != nil {
+		return err
+	}
+	_, err := writer.Write(array.Data)
+	return err
+}
+
+func ReadIDXFile(file_name string) (IDXArray, error) {
+	file, err := os.Open(file_name)
+	if err != nil {
+		return IDXArray{}, err
+	}
+	defer file.Close()
+
+	array, err := ReadIDX(file)
+	if err != nil {
+		return IDXArray{}, fmt.Errorf("%s: %w", file_name, err)
+	}
+	return array, nil
+}
+
+// WriteIDXFile gzips the file when its name ends in .gz
+func WriteIDXFile(file_name string, array IDXArray) error {
+	file, err := os.Create(file_name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if !strings.HasSuffix(file_name, ".gz") {
+		return WriteIDX(file, array)
+	}
+
+	zipped := gzip.NewWriter(file)
+	if err := WriteIDX(zipped, array); err != nil {
+		return err
+	}
+	return zipped.Close()
+}
+
+// IDX is a dataset of an IDX image file of n x rows x columns and an IDX
+// label file of n values. IDX images store ink as high values, the opposite
+// of the images of this project, so gray levels are inverted on the way in
+// and out. the file path of every sample is the image file followed by its
+// index, and as IDX files know nothing about fonts every image is its own
+// family when split
+type IDX struct {
+	Classes   []string // label of every class value, from a label manifest or "0" up to the largest value
+	Transpose bool     // EMNIST stores every image column by column
+	Entries   []Entry
+
+	rows    int
+	columns int
+	pixels  []byte
+}
+
+// OpenIDX opens a pair of IDX files. labels_manifest, such as the one
+// export_idx writes, names the class of every label value in order, one per
+// line. without it the classes are the label values themselves
+func OpenIDX(images_file, labels_file, labels_manifest string) (*IDX, error) {
+	images, err := ReadIDXFile(images_file)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := ReadIDXFile(labels_file)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(images.Dims) != 3 || len(labels.Dims) != 1 || images.Dims[0] != labels.Dims[0] {
+		return nil, fmt.Errorf("images of shape %v do not match labels of shape %v", images.Dims, labels.Dims)
+	}
+	if images.Dims[1] == 0 || images.Dims[2] == 0 {
+		return nil, fmt.Errorf("images of shape %v are empty", images.Dims)
+	}
+
+	idx := &IDX{
+		rows:    images.Dims[1],
+		columns: images.Dims[2],
+		pixels:  images.Data,
+		Entries: make([]Entry, len(labels.Data)),
+	}
+
+	largest := 0
+	name := path.Base(images_file)
+	for i, label := range labels.Data {
+		idx.Entries[i] = Entry{FilePath: fmt.Sprintf("%s/%d", name, i), Label: int(label), Index: i}
+		largest = max(largest, int(label))
+	}
+
+	if labels_manifest == "" {
+		for class := range largest + 1 {
+			idx.Classes = append(idx.Classes, strconv.Itoa(class))
+		}
+		return idx, nil
+	}
+
+	manifest, err := os.Open(labels_manifest)
+	if err != nil {
+		return nil, err
+	}
+	defer manifest.Close()
+
+	classes, err := ReadLabelManifest(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("could not read label manifest %s: %w", labels_manifest, err)
+	}
+	if largest >= len(classes) {
+		return nil, fmt.Errorf("%s lists %d classes but the labels go up to %d", labels_manifest, len(classes), largest)
+	}
+	for _, class := range classes {
+		idx.Classes = append(idx.Classes, class.Label)
+	}
+
+	return idx, nil
+}
+
+func (idx *IDX) Len() int {
+	return len(idx.Entries)
+}
+
+func (idx *IDX) Labels() []string {
+	return idx.Classes
+}
+
+// Close does nothing, the IDX files are read whole
+func (idx *IDX) Close() error {
+	return nil
+}
+
+func (idx *IDX) List() []Entry {
+	return idx.Entries
+}
+
+// Subset keeps the given entries of this dataset
+func (idx *IDX) Subset(entries []Entry) Dataset {
+	return &IDX{
+		Classes:   idx.Classes,
+		Transpose: idx.Transpose,
+		Entries:   entries,
+		rows:      idx.rows,
+		columns:   idx.columns,
+		pixels:    idx.pixels,
+	}
+}
+
+func (idx *IDX) Get(i int) (Sample, error) {
+	if i < 0 || i >= len(idx.Entries) {
+		return Sample{}, fmt.Errorf("sample %d out of range for %d samples", i, len(idx.Entries))
+	}
+
+	entry := idx.Entries[i]
+	n, area := entry.Index, idx.rows*idx.columns
+	if n < 0 || n >= len(idx.pixels)/area {
+		return Sample{}, fmt.Errorf("%s is not an IDX entry", entry.FilePath)
+	}
+
+	pixels := idx.pixels[n*area : (n+1)*area]
+
+	img := image.NewGray(image.Rect(0, 0, idx.columns, idx.rows))
+	if idx.Transpose {
+		img = image.NewGray(image.Rect(0, 0, idx.rows, idx.columns))
+	}
+	for y := range idx.rows {
+		for x := range idx.columns {
+			ink := color.Gray{Y: 255 - pixels[y*idx.columns+x]}
+			if idx.Transpose {
+				img.SetGray(y, x, ink)
+			} else {
+				img.SetGray(x, y, ink)
+			}
+		}
+	}
+
+	return Sample{Image: img, Label: entry.Label, FilePath: entry.FilePath}, nil
+}
+
+// ExportIDX lays every image of a dataset, which must all be the same size,
+// and their labels out as IDX arrays of unsigned bytes
+func ExportIDX(dataset Dataset) (IDXArray, IDXArray, error) {
+	if len(dataset.Labels()) > 256 {
+		return IDXArray{}, IDXArray{}, fmt.Errorf("IDX labels are bytes, %d classes do not fit", len(dataset.Labels()))
+	}
+
+	images := IDXArray{Dims: []int{dataset.Len(), 0, 0}}
+	labels := IDXArray{Dims: []int{dataset.Len()}, Data: make([]byte, 0, dataset.Len())}
+
+	for sample, err := range All(dataset) {
+		if err != nil {
+			return IDXArray{}, IDXArray{}, err
+		}
+
+		bounds := sample.Image.Bounds()
+		if len(labels.Data) == 0 {
+			images.Dims[1], images.Dims[2] = bounds.Dy(), bounds.Dx()
+			images.Data = make([]byte, 0, dataset.Len()*bounds.Dx()*bounds.Dy())
+		}
+		if bounds.Dy() != images.Dims[1] || bounds.Dx() != images.Dims[2] {
+			return IDXArray{}, IDXArray{}, fmt.Errorf("%s is %dx%d but the first image is %dx%d", sample.FilePath, bounds.Dx(), bounds.Dy(), images.Dims[2], images.Dims[1])
+		}
+
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				gray := color.GrayModel.Convert(sample.Image.At(x, y)).(color.Gray)
+				images.Data = append(images.Data, 255-gray.Y)
+			}
+		}
+		labels.Data = append(labels.Data, byte(sample.Label))
+	}
+
+	return images, labels, nil
+}
@@ -0,0 +1,177 @@
+package dataset
+
+import (
+	"bytes"
+	"compress/gzip"
+	"image/color"
+	"os"
+	"path"
+	"slices"
+	"testing"
+)
+
+func TestWriteAndReadIDX(t *testing.T) {
+	array := IDXArray{Dims: []int{2, 3}, Data: []byte{1, 2, 3, 4, 5, 255}}
+
+	plain := bytes.Buffer{}
+	if err := WriteIDX(&plain, array); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(plain.Bytes()[:12], []byte{0, 0, 8, 2, 0, 0, 0, 2, 0, 0, 0, 3}) {
+		t.Errorf("expected an IDX header for 2x3 bytes but got %v", plain.Bytes()[:12])
+	}
+
+	zipped := bytes.Buffer{}
+	writer := gzip.NewWriter(&zipped)
+	writer.Write(plain.Bytes())
+	writer.Close()
+
+	for name, encoded := range map[string][]byte{"plain": plain.Bytes(), "gzip": zipped.Bytes()} {
+		read, err := ReadIDX(bytes.NewReader(encoded))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !slices.Equal(read.Dims, array.Dims) || !slices.Equal(read.Data, array.Data) {
+			t.Errorf("%s: expected %v %v but got %v %v", name, array.Dims, array.Data, read.Dims, read.Data)
+		}
+	}
+}
+
+func TestReadIDXRejectsBadFiles(t *testing.T) {
+	for name, encoded := range map[string][]byte{
+		"bad magic":  {1, 0, 8, 1, 0, 0, 0, 1, 7},
+		"float type": {0, 0, 0x0d, 1, 0, 0, 0, 1, 0, 0, 0, 0},
+		"short data": {0, 0, 8, 1, 0, 0, 0, 3, 7},
+		"huge dims":  {0, 0, 8, 3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		if _, err := ReadIDX(bytes.NewReader(encoded)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func writeIDXFiles(t *testing.T, images, labels IDXArray) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	images_file, labels_file := path.Join(dir, "images-idx3-ubyte.gz"), path.Join(dir, "labels-idx1-ubyte")
+	if err := WriteIDXFile(images_file, images); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteIDXFile(labels_file, labels); err != nil {
+		t.Fatal(err)
+	}
+	return images_file, labels_file
+}
+
+func TestOpenIDXInvertsInkAndCanTranspose(t *testing.T) {
+	images_file, labels_file := writeIDXFiles(t,
+		IDXArray{Dims: []int{2, 2, 3}, Data: []byte{
+			255, 0, 0,
+			0, 0, 0,
+
+			0, 0, 0,
+			0, 0, 200,
+		}},
+		IDXArray{Dims: []int{2}, Data: []byte{4, 1}})
+
+	idx, err := OpenIDX(images_file, labels_file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(idx.Labels(), []string{"0", "1", "2", "3", "4"}) {
+		t.Errorf("expected labels 0 to 4 but got %v", idx.Labels())
+	}
+
+	sample, err := idx.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.Label != 1 || sample.Image.Bounds().Dx() != 3 || sample.Image.At(2, 1) != (color.Gray{Y: 55}) || sample.Image.At(0, 0) != (color.Gray{Y: 255}) {
+		t.Errorf("expected label 1 with ink at 2,1 but got label %d and %v", sample.Label, sample.Image)
+	}
+
+	idx.Transpose = true
+	sample, _ = idx.Get(1)
+	if sample.Image.Bounds().Dx() != 2 || sample.Image.At(1, 2) != (color.Gray{Y: 55}) {
+		t.Errorf("expected the transposed ink at 1,2 but got %v", sample.Image)
+	}
+
+	if FontFamily(idx.Entries[0].FilePath) == FontFamily(idx.Entries[1].FilePath) {
+		t.Errorf("expected every image to be its own family but got %v", idx.Entries)
+	}
+
+	renamed := idx.List()[1]
+	renamed.FilePath = "renamed.png"
+	if sample, err := idx.Subset([]Entry{renamed}).Get(0); err != nil || sample.Label != 1 {
+		t.Errorf("expected the entry to find its image by index whatever its file path, got %v", err)
+	}
+
+	subset := idx.Subset(idx.List()[1:])
+	if sample, _ := subset.Get(0); subset.Len() != 1 || sample.Label != 1 {
+		t.Errorf("expected the subset to hold the second image")
+	}
+}
+
+func TestExportIDXReadsBackTheSameImages(t *testing.T) {
+	dataset_dir := t.TempDir()
+	createImages(t, dataset_dir, "0", "A_0.png", "B_0.png")
+	createImages(t, dataset_dir, "1", "A_1.png")
+
+	folder, err := OpenFolder(dataset_dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, labels, err := ExportIDX(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(images.Dims, []int{3, 2, 2}) || !slices.Equal(labels.Data, []byte{0, 0, 1}) {
+		t.Fatalf("expected 3 images of 2x2 labeled [0 0 1] but got %v labeled %v", images.Dims, labels.Data)
+	}
+
+	images_file, labels_file := writeIDXFiles(t, images, labels)
+	labels_manifest := path.Join(t.TempDir(), LabelManifest)
+	if err := os.WriteFile(labels_manifest, []byte("0 zero\n1 one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIDX(images_file, labels_file, labels_manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(idx.Labels(), []string{"zero", "one"}) {
+		t.Errorf("expected the labels of the manifest but got %v", idx.Labels())
+	}
+	for i := range folder.Len() {
+		expected, _ := folder.Get(i)
+		actual, _ := idx.Get(i)
+		for y := range 2 {
+			for x := range 2 {
+				if color.GrayModel.Convert(expected.Image.At(x, y)) != actual.Image.At(x, y) {
+					t.Errorf("image %d pixel %d,%d: expected %v but got %v", i, x, y, expected.Image.At(x, y), actual.Image.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestOpenIDXRejectsEmptyImagesAndMissingClasses(t *testing.T) {
+	images_file, labels_file := writeIDXFiles(t,
+		IDXArray{Dims: []int{2, 0, 3}},
+		IDXArray{Dims: []int{2}, Data: []byte{0, 1}})
+	if _, err := OpenIDX(images_file, labels_file, ""); err == nil {
+		t.Errorf("expected an error for images without rows")
+	}
+
+	images_file, labels_file = writeIDXFiles(t,
+		IDXArray{Dims: []int{2, 1, 1}, Data: []byte{0, 0}},
+		IDXArray{Dims: []int{2}, Data: []byte{0, 2}})
+	labels_manifest := path.Join(t.TempDir(), LabelManifest)
+	if err := os.WriteFile(labels_manifest, []byte("a\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenIDX(images_file, labels_file, labels_manifest); err == nil {
+		t.Errorf("expected an error for a label value the manifest does not name")
+	}
+}
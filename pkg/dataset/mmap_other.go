@@ -0,0 +1,14 @@
+//go:build !unix
+
+package dataset
+
+import "os"
+
+// mapFile reads the whole file, for platforms without mmap
+func mapFile(file_name string) ([]byte, func() error, error) {
+	contents, err := os.ReadFile(file_name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return contents, func() error { return nil }, nil
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package dataset
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps a file read only into memory
+func mapFile(file_name string) ([]byte, func() error, error) {
+	file, err := os.Open(file_name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		return []byte{}, func() error { return nil }, nil
+	}
+
+	contents, err := syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return contents, func() error { return syscall.Munmap(contents) }, nil
+}
@@ -0,0 +1,332 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"io"
+	"ocr_cnn/pkg/common"
+	"os"
+	"path"
+	"strconv"
+)
+
+const (
+	packedMagic   = "OCRP"
+	PackedVersion = 1
+)
+
+// Packed is a dataset read from a file written by WritePacked. the file is
+// memory mapped where the platform allows it, so after OpenPacked every
+// sample is decoded straight from memory without touching the disk
+//
+// the file holds the magic bytes, a version, the image size, the labels,
+// the font families, then the label, font and file name of every sample
+// and every image as one bit per pixel, rows first, each image padded to a
+// whole byte. a black pixel is 0. a CRC-32 of everything before it ends the
+// file. numbers are little endian uint64 like in model files
+type Packed struct {
+	Width   int
+	Height  int
+	Classes []string
+	Fonts   []string
+	Entries []Entry
+
+	name    string
+	fonts   []int  // font of every sample
+	bits    []byte // the images of every sample
+	release func() error
+}
+
+// OpenPacked maps a packed file and checks its checksum. Close releases it
+func OpenPacked(file_name string) (*Packed, error) {
+	contents, release, err := mapFile(file_name)
+	if err != nil {
+		return nil, err
+	}
+
+	packed, err := decodePacked(contents)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("%s: %w", file_name, err)
+	}
+
+	packed.name = path.Base(file_name)
+	for i := range packed.Entries {
+		packed.Entries[i].FilePath = path.Join(packed.name, strconv.Itoa(i), packed.Entries[i].FilePath)
+	}
+	packed.release = release
+	return packed, nil
+}
+
+func decodePacked(contents []byte) (*Packed, error) {
+	if len(contents) < len(packedMagic)+4 || string(contents[:len(packedMagic)]) != packedMagic {
+		return nil, errors.New("not a packed dataset")
+	}
+
+	body := contents[:len(contents)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(contents[len(body):]) {
+		return nil, errors.New("checksum does not match, the file is corrupt")
+	}
+
+	in := packedReader{data: body, offset: len(packedMagic)}
+	if version := in.uint(); in.err == nil && version != PackedVersion {
+		return nil, fmt.Errorf("unsupported packed dataset version %d", version)
+	}
+
+	packed := &Packed{Width: in.count(), Height: in.count()}
+	for range in.count() {
+		packed.Classes = append(packed.Classes, in.string())
+	}
+	for range in.count() {
+		packed.Fonts = append(packed.Fonts, in.string())
+	}
+
+	sampleCount := in.count()
+	for range sampleCount {
+		if in.err != nil {
+			break
+		}
+
+		label, font := in.count(), in.count()
+		if in.err == nil && (label >= len(packed.Classes) || font >= len(packed.Fonts)) {
+			in.err = fmt.Errorf("sample with label %d and font %d is out of range", label, font)
+		}
+		packed.Entries = append(packed.Entries, Entry{FilePath: in.string(), Label: label, Index: len(packed.Entries)})
+		packed.fonts = append(packed.fonts, font)
+	}
+
+	packed.bits = in.rest()
+	// the sizes are only bounded by the file length, so their products are
+	// checked against the bytes of the images before they are taken
+	if in.err == nil && packed.Height > 0 && packed.Width > 8*(len(packed.bits)+1)/packed.Height {
+		in.err = fmt.Errorf("images of %dx%d are larger than the file", packed.Width, packed.Height)
+	}
+	if in.err == nil && packed.imageBytes() > 0 && sampleCount > len(packed.bits)/packed.imageBytes() {
+		in.err = fmt.Errorf("%d images of %dx%d are larger than the file", sampleCount, packed.Width, packed.Height)
+	}
+	if in.err == nil && len(packed.bits) != sampleCount*packed.imageBytes() {
+		in.err = fmt.Errorf("%d bytes of images do not fit %d images of %dx%d", len(packed.bits), sampleCount, packed.Width, packed.Height)
+	}
+	if in.err != nil {
+		return nil, in.err
+	}
+
+	return packed, nil
+}
+
+func (packed *Packed) imageBytes() int {
+	return (packed.Width*packed.Height + 7) / 8
+}
+
+func (packed *Packed) Close() error {
+	return packed.release()
+}
+
+func (packed *Packed) Len() int {
+	return len(packed.Entries)
+}
+
+func (packed *Packed) Labels() []string {
+	return packed.Classes
+}
+
+func (packed *Packed) List() []Entry {
+	return packed.Entries
+}
+
+// Subset keeps the given entries and shares the mapped file, which stays
+// open until the Packed it came from is closed
+func (packed *Packed) Subset(entries []Entry) Dataset {
+	subset := *packed
+	subset.Entries = entries
+	return &subset
+}
+
+func (packed *Packed) Get(i int) (Sample, error) {
+	if i < 0 || i >= len(packed.Entries) {
+		return Sample{}, fmt.Errorf("sample %d out of range for %d samples", i, len(packed.Entries))
+	}
+
+	entry := packed.Entries[i]
+	n := entry.Index
+	if n < 0 || n >= len(packed.fonts) {
+		return Sample{}, fmt.Errorf("%s is not a packed entry", entry.FilePath)
+	}
+
+	bits := packed.bits[n*packed.imageBytes() : (n+1)*packed.imageBytes()]
+	img := image.NewGray(image.Rect(0, 0, packed.Width, packed.Height))
+	for p := range packed.Width * packed.Height {
+		if bits[p/8]&(0x80>>(p%8)) != 0 {
+			img.Pix[p] = 255
+		}
+	}
+
+	return Sample{
+		Image:    img,
+		Label:    entry.Label,
+		FilePath: entry.FilePath,
+		Font:     packed.Fonts[packed.fonts[n]],
+	}, nil
+}
+
+// WritePacked binarizes every image of a dataset, which must all be the
+// same size, and writes them in the format OpenPacked reads
+func WritePacked(writer io.Writer, dataset Dataset) error {
+	packed := Packed{Classes: dataset.Labels(), Fonts: []string{}}
+	fontIdxs := map[string]int{}
+
+	for sample, err := range All(dataset) {
+		if err != nil {
+			return err
+		}
+
+		bounds := sample.Image.Bounds()
+		if len(packed.Entries) == 0 {
+			packed.Width, packed.Height = bounds.Dx(), bounds.Dy()
+		}
+		if bounds.Dx() != packed.Width || bounds.Dy() != packed.Height {
+			return fmt.Errorf("%s is %dx%d but the first image is %dx%d", sample.FilePath, bounds.Dx(), bounds.Dy(), packed.Width, packed.Height)
+		}
+
+		if _, found := fontIdxs[sample.Font]; !found {
+			fontIdxs[sample.Font] = len(packed.Fonts)
+			packed.Fonts = append(packed.Fonts, sample.Font)
+		}
+		packed.Entries = append(packed.Entries, Entry{FilePath: path.Base(sample.FilePath), Label: sample.Label})
+		packed.fonts = append(packed.fonts, fontIdxs[sample.Font])
+
+		binarized := common.Binarize(sample.Image)
+		bits := make([]byte, packed.imageBytes())
+		for y := range packed.Height {
+			for x := range packed.Width {
+				if binarized.At(bounds.Min.X+x, bounds.Min.Y+y) != (color.RGBA{0, 0, 0, 255}) {
+					p := y*packed.Width + x
+					bits[p/8] |= 0x80 >> (p % 8)
+				}
+			}
+		}
+		packed.bits = append(packed.bits, bits...)
+	}
+
+	checksum := crc32.NewIEEE()
+	buffered := bufio.NewWriter(io.MultiWriter(writer, checksum))
+	out := packedWriter{writer: buffered}
+
+	out.bytes([]byte(packedMagic))
+	out.uint(PackedVersion)
+	out.uint(uint64(packed.Width))
+	out.uint(uint64(packed.Height))
+	out.strings(packed.Classes)
+	out.strings(packed.Fonts)
+	out.uint(uint64(len(packed.Entries)))
+	for i, entry := range packed.Entries {
+		out.uint(uint64(entry.Label))
+		out.uint(uint64(packed.fonts[i]))
+		out.string(entry.FilePath)
+	}
+	out.bytes(packed.bits)
+
+	if out.err != nil {
+		return out.err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	_, err := writer.Write(binary.LittleEndian.AppendUint32(nil, checksum.Sum32()))
+	return err
+}
+
+// WritePackedFile packs a dataset into a new file
+func WritePackedFile(file_name string, dataset Dataset) error {
+	file, err := os.Create(file_name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := WritePacked(file, dataset); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+type packedWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (out *packedWriter) bytes(value []byte) {
+	if out.err == nil {
+		_, out.err = out.writer.Write(value)
+	}
+}
+
+func (out *packedWriter) uint(value uint64) {
+	out.bytes(binary.LittleEndian.AppendUint64(nil, value))
+}
+
+func (out *packedWriter) string(value string) {
+	out.uint(uint64(len(value)))
+	out.bytes([]byte(value))
+}
+
+func (out *packedWriter) strings(values []string) {
+	out.uint(uint64(len(values)))
+	for _, value := range values {
+		out.string(value)
+	}
+}
+
+// packedReader walks a mapped file without copying the images out of it
+type packedReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (in *packedReader) bytes(size int) []byte {
+	if in.err == nil && (size < 0 || size > len(in.data)-in.offset) {
+		in.err = errors.New("file ends early")
+	}
+	if in.err != nil {
+		return nil
+	}
+
+	value := in.data[in.offset : in.offset+size]
+	in.offset += size
+	return value
+}
+
+func (in *packedReader) uint() uint64 {
+	value := in.bytes(8)
+	if in.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(value)
+}
+
+// count is a uint that has to fit in what is left of the file, so a corrupt
+// file cannot ask for huge allocations
+func (in *packedReader) count() int {
+	value := in.uint()
+	if in.err == nil && value > uint64(len(in.data)) {
+		in.err = fmt.Errorf("count %d is larger than the file", value)
+	}
+	if in.err != nil {
+		return 0
+	}
+	return int(value)
+}
+
+func (in *packedReader) string() string {
+	return string(in.bytes(in.count()))
+}
+
+func (in *packedReader) rest() []byte {
+	return in.bytes(len(in.data) - in.offset)
+}
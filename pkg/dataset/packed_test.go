@@ -0,0 +1,139 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/color"
+	"ocr_cnn/pkg/common"
+	"os"
+	"path"
+	"slices"
+	"testing"
+)
+
+func packFolder(t *testing.T) (*Folder, string) {
+	t.Helper()
+
+	dataset_dir := t.TempDir()
+	createImages(t, dataset_dir, "0", "Arial_0.png", "Abadi_0_1.png")
+	createImages(t, dataset_dir, "1", "Arial_1.png")
+
+	folder, err := OpenFolder(dataset_dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file_name := path.Join(t.TempDir(), "dataset.pack")
+	if err := WritePackedFile(file_name, folder); err != nil {
+		t.Fatal(err)
+	}
+	return folder, file_name
+}
+
+func TestPackedReadsBackTheBinarizedImages(t *testing.T) {
+	folder, file_name := packFolder(t)
+
+	packed, err := OpenPacked(file_name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer packed.Close()
+
+	if !slices.Equal(packed.Labels(), folder.Labels()) || packed.Len() != folder.Len() {
+		t.Fatalf("expected %d images of %v but got %d of %v", folder.Len(), folder.Labels(), packed.Len(), packed.Labels())
+	}
+
+	for i := range folder.Len() {
+		expected, _ := folder.Get(i)
+		actual, err := packed.Get(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Label != expected.Label || actual.Font != expected.Font || FontFamily(actual.FilePath) != expected.Font {
+			t.Errorf("sample %d: expected label %d font %s but got label %d font %s path %s", i, expected.Label, expected.Font, actual.Label, actual.Font, actual.FilePath)
+		}
+
+		binarized := common.Binarize(expected.Image)
+		for y := range 2 {
+			for x := range 2 {
+				white := binarized.At(x, y) != (color.RGBA{0, 0, 0, 255})
+				if white != (actual.Image.At(x, y) == color.Gray{Y: 255}) {
+					t.Errorf("sample %d pixel %d,%d: expected white %t but got %v", i, x, y, white, actual.Image.At(x, y))
+				}
+			}
+		}
+	}
+
+	renamed := packed.List()[2]
+	renamed.FilePath = "renamed.png"
+	if sample, err := packed.Subset([]Entry{renamed}).Get(0); err != nil || sample.Label != 1 {
+		t.Errorf("expected the entry to find its image by index whatever its file path, got %v", err)
+	}
+
+	subset := packed.Subset(packed.List()[2:])
+	if sample, err := subset.Get(0); err != nil || sample.Label != 1 {
+		t.Errorf("expected the subset to hold the image of class 1 but got %d %v", sample.Label, err)
+	}
+}
+
+func TestOpenPackedRejectsDamagedFiles(t *testing.T) {
+	_, file_name := packFolder(t)
+	contents, err := os.ReadFile(file_name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := slices.Clone(contents)
+	flipped[len(flipped)-10] ^= 1
+
+	for name, damaged := range map[string][]byte{
+		"flipped bit": flipped,
+		"truncated":   contents[:len(contents)/2],
+		"empty":       {},
+		"not packed":  []byte("OCRN and more bytes"),
+	} {
+		damaged_file := path.Join(t.TempDir(), "damaged.pack")
+		if err := os.WriteFile(damaged_file, damaged, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if packed, err := OpenPacked(damaged_file); err == nil {
+			packed.Close()
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDecodePackedRejectsImagesLargerThanTheFile(t *testing.T) {
+	buffer := bytes.Buffer{}
+	out := packedWriter{writer: &buffer}
+	out.bytes([]byte(packedMagic))
+	out.uint(PackedVersion)
+	out.uint(60) // width
+	out.uint(60) // height
+	out.strings([]string{"0"})
+	out.strings([]string{"Arial"})
+	out.uint(1)
+	out.uint(0)
+	out.uint(0)
+	out.string("a.png")
+	out.bytes(make([]byte, 20))
+	contents := binary.LittleEndian.AppendUint32(buffer.Bytes(), crc32.ChecksumIEEE(buffer.Bytes()))
+
+	if _, err := decodePacked(contents); err == nil {
+		t.Errorf("expected an error for images larger than the file")
+	}
+}
+
+func TestCloseReleasesTheMappedFile(t *testing.T) {
+	_, file_name := packFolder(t)
+
+	opened, err := Open(file_name, "", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := opened.Close(); err != nil {
+		t.Errorf("expected the mapped file to be released but got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package dataset
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// trailing variant numbers such as the _3_1 of ArialNova_3_1.png
+var fontVariant = regexp.MustCompile(`(_[0-9]+)+$`)
+
+// FontFamily is the font an image was rendered with, taken from its file
+// name without the variant numbers
+func FontFamily(file_path string) string {
+	name := strings.TrimSuffix(path.Base(file_path), path.Ext(file_path))
+	return fontVariant.ReplaceAllString(name, "")
+}
+
+// Entry is an image file and the index of its class
+type Entry struct {
+	FilePath string
+	Label    int
+	Index    int // position of the image in the file it shares with others, such as an IDX file
+}
+
+// Split is the entries of a dataset divided into training, validation and test
+type Split struct {
+	Train      []Entry
+	Validation []Entry
+	Test       []Entry
+}
+
+// SplitEntries puts every font family in exactly one of train, validation
+// and test, so a model is always evaluated on typefaces it has not seen.
+// families are visited in random order and each goes to the split that is
+// furthest from its share of the family's classes, which keeps the classes of
+// every split close to the requested fractions
+func SplitEntries(entries []Entry, validationFraction, testFraction float64, rng *rand.Rand) (Split, error) {
+	if !(validationFraction >= 0 && testFraction >= 0 && validationFraction+testFraction < 1) {
+		return Split{}, fmt.Errorf("invalid split fractions: validation %g test %g", validationFraction, testFraction)
+	}
+
+	families := map[string][]Entry{}
+	classCounts := map[int]int{}
+	for _, entry := range entries {
+		family := FontFamily(entry.FilePath)
+		families[family] = append(families[family], entry)
+		classCounts[entry.Label]++
+	}
+
+	names := []string{}
+	for name := range families {
+		names = append(names, name)
+	}
+	slices.Sort(names) // map order is random, so sort before shuffling to stay reproducible
+	rng.Shuffle(len(names), func(i, j int) {
+		names[i], names[j] = names[j], names[i]
+	})
+
+	fractions := []float64{1 - validationFraction - testFraction, validationFraction, testFraction}
+	splits := make([][]Entry, len(fractions))
+	splitClassCounts := make([]map[int]int, len(fractions))
+	for s := range splitClassCounts {
+		splitClassCounts[s] = map[int]int{}
+	}
+
+	for _, name := range names {
+		family := families[name]
+
+		best, bestFill := 0, float64(0)
+		for s, fraction := range fractions {
+			if fraction == 0 {
+				continue
+			}
+
+			// how full the split already is for the classes of this family
+			fill := float64(0)
+			for _, entry := range family {
+				target := fraction * float64(classCounts[entry.Label])
+				fill += float64(splitClassCounts[s][entry.Label]) / target
+			}
+
+			if s == 0 || fill < bestFill {
+				best, bestFill = s, fill
+			}
+		}
+
+		splits[best] = append(splits[best], family...)
+		for _, entry := range family {
+			splitClassCounts[best][entry.Label]++
+		}
+	}
+
+	return Split{
+		Train:      splits[0],
+		Validation: splits[1],
+		Test:       splits[2],
+	}, nil
+}
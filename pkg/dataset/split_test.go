@@ -0,0 +1,117 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestFontFamilyStripsVariantNumbers(t *testing.T) {
+	for file_path, expected := range map[string]string{
+		"dataset/0/Abadi_0.png":         "Abadi",
+		"dataset/3/ArialNova_3_1.png":   "ArialNova",
+		"Engravers_MT_3.png":            "Engravers_MT",
+		"dataset/9/Bahnschrift_0_1.png": "Bahnschrift",
+	} {
+		if actual := FontFamily(file_path); actual != expected {
+			t.Errorf("%s: expected %s but got %s", file_path, expected, actual)
+		}
+	}
+}
+
+func createFontEntries(familyCount int) []Entry {
+	entries := []Entry{}
+	for f := range familyCount {
+		for label := range 10 {
+			entries = append(entries,
+				Entry{FilePath: fmt.Sprintf("%d/Font%c_%d.png", label, 'A'+f, label), Label: label},
+				Entry{FilePath: fmt.Sprintf("%d/Font%c_%d_1.png", label, 'A'+f, label), Label: label},
+			)
+		}
+	}
+	return entries
+}
+
+func TestSplitEntriesKeepsFontFamiliesTogether(t *testing.T) {
+	entries := createFontEntries(20)
+	split, err := SplitEntries(entries, .2, .2, rand.New(rand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total := len(split.Train) + len(split.Validation) + len(split.Test); total != len(entries) {
+		t.Fatalf("expected %d entries across the splits but got %d", len(entries), total)
+	}
+
+	familySplits := map[string]string{}
+	for name, part := range map[string][]Entry{"train": split.Train, "validation": split.Validation, "test": split.Test} {
+		classes := map[int]int{}
+		for _, entry := range part {
+			family := FontFamily(entry.FilePath)
+			if other, ok := familySplits[family]; ok && other != name {
+				t.Errorf("font family %s is in both %s and %s", family, other, name)
+			}
+			familySplits[family] = name
+			classes[entry.Label]++
+		}
+
+		for label := range 10 {
+			if classes[label] != len(part)/10 {
+				t.Errorf("%s: expected %d images of digit %d but got %d", name, len(part)/10, label, classes[label])
+			}
+		}
+	}
+
+	if len(split.Validation) != 4*20 || len(split.Test) != 4*20 {
+		t.Errorf("expected 4 families in validation and test but got %d and %d images", len(split.Validation), len(split.Test))
+	}
+}
+
+func TestSplitEntriesBalancesUnevenFamilies(t *testing.T) {
+	entries := createFontEntries(10)
+	// one family only has the digit 1, so families are not interchangeable
+	for range 6 {
+		entries = append(entries, Entry{FilePath: "1/Ones_0.png", Label: 1})
+	}
+
+	split, err := SplitEntries(entries, .25, 0, rand.New(rand.NewPCG(3, 4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(split.Test) != 0 {
+		t.Errorf("expected no test images but got %d", len(split.Test))
+	}
+	for label := range 10 {
+		count := 0
+		for _, entry := range split.Validation {
+			if entry.Label == label {
+				count++
+			}
+		}
+		if count == 0 {
+			t.Errorf("expected validation to contain digit %d", label)
+		}
+	}
+}
+
+func TestSplitEntriesIsReproducible(t *testing.T) {
+	entries := createFontEntries(20)
+
+	first, _ := SplitEntries(entries, .1, .1, rand.New(rand.NewPCG(5, 6)))
+	second, _ := SplitEntries(entries, .1, .1, rand.New(rand.NewPCG(5, 6)))
+
+	if !slices.Equal(first.Validation, second.Validation) || !slices.Equal(first.Test, second.Test) {
+		t.Errorf("expected the same seed to give the same split")
+	}
+}
+
+func TestSplitEntriesRejectsBadFractions(t *testing.T) {
+	for _, fractions := range [][2]float64{{-.1, .1}, {.1, -.1}, {.5, .5}, {math.NaN(), .1}} {
+		if _, err := SplitEntries(createFontEntries(2), fractions[0], fractions[1], rand.New(rand.NewPCG(7, 8))); err == nil {
+			t.Errorf("expected an error for fractions %v", fractions)
+		}
+	}
+}
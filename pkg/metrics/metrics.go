@@ -0,0 +1,196 @@
+package metrics
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Evaluation counts predictions of a classifier, both per class and per font
+type Evaluation struct {
+	Labels    []string
+	Confusion [][]int // Confusion[actual][predicted]
+	fonts     map[string]*FontAccuracy
+}
+
+type ClassMetrics struct {
+	Label     string  `json:"label"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"` // number of samples of the class
+}
+
+type FontAccuracy struct {
+	Font     string  `json:"font"`
+	Correct  int     `json:"correct"`
+	Total    int     `json:"total"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+type Report struct {
+	Samples   int            `json:"samples"`
+	Accuracy  float64        `json:"accuracy"`
+	Labels    []string       `json:"labels"`
+	Confusion [][]int        `json:"confusion"`
+	Classes   []ClassMetrics `json:"classes"`
+	Fonts     []FontAccuracy `json:"fonts"`
+}
+
+func CreateEvaluation(labels []string) *Evaluation {
+	confusion := make([][]int, len(labels))
+	for i := range confusion {
+		confusion[i] = make([]int, len(labels))
+	}
+
+	return &Evaluation{
+		Labels:    labels,
+		Confusion: confusion,
+		fonts:     map[string]*FontAccuracy{},
+	}
+}
+
+func (evaluation *Evaluation) Add(actual, predicted int, font string) {
+	evaluation.Confusion[actual][predicted]++
+
+	fontAccuracy, ok := evaluation.fonts[font]
+	if !ok {
+		fontAccuracy = &FontAccuracy{Font: font}
+		evaluation.fonts[font] = fontAccuracy
+	}
+	fontAccuracy.Total++
+	if actual == predicted {
+		fontAccuracy.Correct++
+	}
+}
+
+// Report computes the metrics of everything added so far. fonts are sorted
+// from the least to the most accurate
+func (evaluation *Evaluation) Report() Report {
+	report := Report{
+		Labels:    evaluation.Labels,
+		Confusion: evaluation.Confusion,
+	}
+
+	correct := 0
+	for class, label := range evaluation.Labels {
+		truePositives := evaluation.Confusion[class][class]
+		actual, predicted := 0, 0
+		for other := range evaluation.Labels {
+			actual += evaluation.Confusion[class][other]
+			predicted += evaluation.Confusion[other][class]
+		}
+
+		precision := ratio(truePositives, predicted)
+		recall := ratio(truePositives, actual)
+		f1 := float64(0)
+		if precision+recall > 0 {
+			f1 = 2 * precision * recall / (precision + recall)
+		}
+
+		report.Classes = append(report.Classes, ClassMetrics{
+			Label:     label,
+			Precision: precision,
+			Recall:    recall,
+			F1:        f1,
+			Support:   actual,
+		})
+		report.Samples += actual
+		correct += truePositives
+	}
+	report.Accuracy = ratio(correct, report.Samples)
+
+	for _, fontAccuracy := range evaluation.fonts {
+		fontAccuracy.Accuracy = ratio(fontAccuracy.Correct, fontAccuracy.Total)
+		report.Fonts = append(report.Fonts, *fontAccuracy)
+	}
+	slices.SortFunc(report.Fonts, func(a, b FontAccuracy) int {
+		return cmp.Or(cmp.Compare(a.Accuracy, b.Accuracy), strings.Compare(a.Font, b.Font))
+	})
+
+	return report
+}
+
+// ratio is 0 instead of NaN when there is nothing to divide by
+func ratio(numerator, denominator int) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+func (report Report) WriteText(writer io.Writer) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(table, "accuracy: %.4f (%d samples)\n\n", report.Accuracy, report.Samples)
+
+	fmt.Fprintf(table, "actual \\ predicted\t%s\t\n", strings.Join(report.Labels, "\t"))
+	for class, row := range report.Confusion {
+		fmt.Fprintf(table, "%s\t", report.Labels[class])
+		for _, count := range row {
+			fmt.Fprintf(table, "%d\t", count)
+		}
+		fmt.Fprintln(table)
+	}
+
+	fmt.Fprintf(table, "\nclass\tprecision\trecall\tf1\tsupport\t\n")
+	for _, class := range report.Classes {
+		fmt.Fprintf(table, "%s\t%.4f\t%.4f\t%.4f\t%d\t\n", class.Label, class.Precision, class.Recall, class.F1, class.Support)
+	}
+
+	fmt.Fprintf(table, "\nfont\tcorrect\ttotal\taccuracy\t\n")
+	for _, font := range report.Fonts {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.4f\t\n", font.Font, font.Correct, font.Total, font.Accuracy)
+	}
+
+	return table.Flush()
+}
+
+// WriteCSV writes the overall accuracy, confusion matrix, class metrics and
+// font accuracies as tables separated by empty lines
+func (report Report) WriteCSV(writer io.Writer) error {
+	out := csv.NewWriter(writer)
+
+	out.Write([]string{"samples", "accuracy"})
+	out.Write([]string{fmt.Sprint(report.Samples), formatFloat(report.Accuracy)})
+
+	out.Write(nil)
+	out.Write(append([]string{"actual\\predicted"}, report.Labels...))
+	for class, row := range report.Confusion {
+		record := []string{report.Labels[class]}
+		for _, count := range row {
+			record = append(record, fmt.Sprint(count))
+		}
+		out.Write(record)
+	}
+
+	out.Write(nil)
+	out.Write([]string{"class", "precision", "recall", "f1", "support"})
+	for _, class := range report.Classes {
+		out.Write([]string{class.Label, formatFloat(class.Precision), formatFloat(class.Recall), formatFloat(class.F1), fmt.Sprint(class.Support)})
+	}
+
+	out.Write(nil)
+	out.Write([]string{"font", "correct", "total", "accuracy"})
+	for _, font := range report.Fonts {
+		out.Write([]string{font.Font, fmt.Sprint(font.Correct), fmt.Sprint(font.Total), formatFloat(font.Accuracy)})
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func formatFloat(value float64) string {
+	return fmt.Sprintf("%.6f", value)
+}
+
+func (report Report) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func createSampleEvaluation() *Evaluation {
+	evaluation := CreateEvaluation([]string{"1", "7", "9"})
+	evaluation.Add(0, 0, "Abadi")
+	evaluation.Add(0, 0, "Arial")
+	evaluation.Add(0, 1, "Arial") // a 1 mistaken for a 7
+	evaluation.Add(1, 1, "Abadi")
+	evaluation.Add(1, 1, "Arial")
+	evaluation.Add(2, 1, "Arial")
+	return evaluation
+}
+
+func TestReportCountsPredictions(t *testing.T) {
+	report := createSampleEvaluation().Report()
+
+	if report.Samples != 6 {
+		t.Errorf("expected 6 samples but got %d", report.Samples)
+	}
+	if report.Accuracy != 4.0/6 {
+		t.Errorf("expected accuracy %f but got %f", 4.0/6, report.Accuracy)
+	}
+	if report.Confusion[0][1] != 1 || report.Confusion[2][1] != 1 || report.Confusion[1][0] != 0 {
+		t.Errorf("unexpected confusion matrix %v", report.Confusion)
+	}
+}
+
+func TestReportClassMetrics(t *testing.T) {
+	report := createSampleEvaluation().Report()
+
+	expected := []ClassMetrics{
+		{Label: "1", Precision: 1, Recall: 2.0 / 3, F1: 0.8, Support: 3},
+		{Label: "7", Precision: 2.0 / 4, Recall: 1, F1: 2.0 / 3, Support: 2},
+		{Label: "9", Precision: 0, Recall: 0, F1: 0, Support: 1}, // never predicted
+	}
+
+	for i, class := range report.Classes {
+		if class.Label != expected[i].Label || class.Support != expected[i].Support ||
+			math.Abs(class.Precision-expected[i].Precision) > 1e-12 ||
+			math.Abs(class.Recall-expected[i].Recall) > 1e-12 ||
+			math.Abs(class.F1-expected[i].F1) > 1e-12 {
+			t.Errorf("class %d: expected %+v but got %+v", i, expected[i], class)
+		}
+	}
+}
+
+func TestReportSortsFontsByAccuracy(t *testing.T) {
+	report := createSampleEvaluation().Report()
+
+	if len(report.Fonts) != 2 {
+		t.Fatalf("expected 2 fonts but got %d", len(report.Fonts))
+	}
+	if report.Fonts[0] != (FontAccuracy{Font: "Arial", Correct: 2, Total: 4, Accuracy: .5}) {
+		t.Errorf("expected Arial to be the least accurate font but got %+v", report.Fonts[0])
+	}
+	if report.Fonts[1] != (FontAccuracy{Font: "Abadi", Correct: 2, Total: 2, Accuracy: 1}) {
+		t.Errorf("expected Abadi to be the most accurate font but got %+v", report.Fonts[1])
+	}
+}
+
+func TestReportFormats(t *testing.T) {
+	report := createSampleEvaluation().Report()
+
+	text := bytes.Buffer{}
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "accuracy: 0.6667 (6 samples)") {
+		t.Errorf("expected text to start with the accuracy but got:\n%s", text.String())
+	}
+
+	csv := bytes.Buffer{}
+	if err := report.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"actual\\predicted,1,7,9", "1,2,1,0", "7,0.500000,1.000000,0.666667,2", "Arial,2,4,0.500000"} {
+		if !strings.Contains(csv.String(), line+"\n") {
+			t.Errorf("expected csv to contain line %q but got:\n%s", line, csv.String())
+		}
+	}
+
+	encoded := bytes.Buffer{}
+	if err := report.WriteJSON(&encoded); err != nil {
+		t.Fatal(err)
+	}
+	decoded := Report{}
+	if err := json.Unmarshal(encoded.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Samples != report.Samples || decoded.Confusion[2][1] != 1 || decoded.Fonts[0].Font != "Arial" {
+		t.Errorf("expected json to round trip but got %+v", decoded)
+	}
+}
@@ -0,0 +1,129 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+)
+
+type activationConstructor struct {
+	defaults []float64 // options used when none are given
+	create   func(options []float64) Layer
+}
+
+// activations by name, used by CreateActivation and when loading a model
+var activationsByName = map[string]activationConstructor{
+	"relu":       {create: func([]float64) Layer { return CreateReLU() }},
+	"leaky_relu": {defaults: []float64{.01}, create: func(options []float64) Layer { return CreateLeakyReLU(options[0]) }},
+	"prelu":      {defaults: []float64{.25}, create: func(options []float64) Layer { return CreatePReLU(options[0]) }},
+	"elu":        {defaults: []float64{1}, create: func(options []float64) Layer { return CreateELU(options[0]) }},
+	"gelu":       {create: func([]float64) Layer { return CreateGELU() }},
+	"sigmoid":    {create: func([]float64) Layer { return CreateSigmoid() }},
+	"tanh":       {create: func([]float64) Layer { return CreateTanh() }},
+	"identity":   {create: func([]float64) Layer { return CreateIdentity() }},
+}
+
+func ActivationNames() []string {
+	names := []string{}
+	for name := range activationsByName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// CreateActivation creates the activation layer called name. options are
+// the slope of leaky_relu, the initial slope of prelu and the alpha of elu,
+// and take a default value when left out
+func CreateActivation(name string, options ...float64) (Layer, error) {
+	constructor, ok := activationsByName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown activation %q", name)
+	}
+
+	if len(options) == 0 {
+		options = constructor.defaults
+	}
+	if len(options) != len(constructor.defaults) {
+		return nil, fmt.Errorf("%s activation expects %d options but has %d", name, len(constructor.defaults), len(options))
+	}
+
+	return constructor.create(options), nil
+}
+
+func CreateLeakyReLU(slope float64) *ActivationLayer {
+	return &ActivationLayer{
+		Name:       "leaky_relu",
+		Options:    []float64{slope},
+		Function:   func(x float64) float64 { return common.LeakyReLU(x, slope) },
+		Derivative: func(x float64) float64 { return common.LeakyReLUDerivative(x, slope) },
+	}
+}
+
+func CreateELU(alpha float64) *ActivationLayer {
+	return &ActivationLayer{
+		Name:       "elu",
+		Options:    []float64{alpha},
+		Function:   func(x float64) float64 { return common.ELU(x, alpha) },
+		Derivative: func(x float64) float64 { return common.ELUDerivative(x, alpha) },
+	}
+}
+
+func CreateGELU() *ActivationLayer {
+	return &ActivationLayer{Name: "gelu", Function: common.GELU, Derivative: common.GELUDerivative}
+}
+
+func CreateSigmoid() *ActivationLayer {
+	return &ActivationLayer{Name: "sigmoid", Function: common.Sigmoid, Derivative: common.SigmoidDerivative}
+}
+
+func CreateTanh() *ActivationLayer {
+	return &ActivationLayer{Name: "tanh", Function: math.Tanh, Derivative: common.TanhDerivative}
+}
+
+// PReLU is a leaky ReLU that learns the slope of negative inputs. the slope
+// is shared by every feature
+type PReLU struct {
+	Slope *Parameter
+
+	input *tensor.Tensor
+}
+
+func CreatePReLU(initialSlope float64) *PReLU {
+	prelu := &PReLU{Slope: createParameter(1)}
+	prelu.Slope.Value.Data[0] = initialSlope
+	return prelu
+}
+
+func (prelu *PReLU) Forward(input *tensor.Tensor) *tensor.Tensor {
+	prelu.input = input
+	output := tensor.New(input.Shape...)
+
+	slope := prelu.Slope.Value.Data[0]
+	for i, x := range input.Data {
+		output.Data[i] = common.LeakyReLU(x, slope)
+	}
+
+	return output
+}
+
+func (prelu *PReLU) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	inputGradient := tensor.New(outputGradient.Shape...)
+
+	slope := prelu.Slope.Value.Data[0]
+	for i, gradient := range outputGradient.Data {
+		x := prelu.input.Data[i]
+		inputGradient.Data[i] = gradient * common.LeakyReLUDerivative(x, slope)
+		if x <= 0 {
+			prelu.Slope.Gradient.Data[0] += gradient * x
+		}
+	}
+
+	return inputGradient
+}
+
+func (prelu *PReLU) Parameters() []*Parameter {
+	return []*Parameter{prelu.Slope}
+}
@@ -0,0 +1,108 @@
+package neuron
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+	"testing"
+)
+
+func TestActivationsBackwardMatchFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(23, 24))
+
+	for _, name := range ActivationNames() {
+		activation, err := CreateActivation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(name, func(t *testing.T) {
+			checkLayerGradients(t, activation, randomTensor(rng, 2, 6), rng)
+		})
+	}
+}
+
+func TestCreateActivationOptions(t *testing.T) {
+	activation, err := CreateActivation("leaky_relu", .2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := activation.Forward(tensor.FromSlice([]float64{-1, 2}, 1, 2))
+	if !slices.Equal(output.Data, []float64{-.2, 2}) {
+		t.Errorf("expected [-0.2 2] but got %v", output.Data)
+	}
+
+	if _, err := CreateActivation("relu", 1); err == nil {
+		t.Errorf("expected an error for an option relu does not have")
+	}
+	if _, err := CreateActivation("swish"); err == nil {
+		t.Errorf("expected an error for an unknown activation")
+	}
+}
+
+func TestPReLULearnsItsSlope(t *testing.T) {
+	prelu := CreatePReLU(.25)
+
+	prelu.Forward(tensor.FromSlice([]float64{-2, 3}, 1, 2))
+	prelu.Backward(tensor.FromSlice([]float64{1, 1}, 1, 2))
+	UpdateParameters(prelu.Parameters(), .1)
+
+	// only the negative input contributes to the slope gradient
+	if prelu.Slope.Value.Data[0] != .25-.1*-2 {
+		t.Errorf("expected slope %f but got %f", .25-.1*-2, prelu.Slope.Value.Data[0])
+	}
+}
+
+func TestCreateANNWithHiddenActivations(t *testing.T) {
+	ann := CreateANN(func(int) float64 { return .1 }, 16, 3, WithHiddenActivations(
+		func() Layer { return CreateTanh() },
+		func() Layer { return CreateLeakyReLU(.1) },
+	))
+
+	names := []string{}
+	for _, layer := range ann.Layers {
+		if activation, ok := layer.(*ActivationLayer); ok {
+			names = append(names, activation.Name)
+		}
+	}
+
+	if !slices.Equal(names, []string{"tanh", "leaky_relu", "leaky_relu"}) {
+		t.Errorf("expected tanh followed by leaky_relu but got %v", names)
+	}
+}
+
+func TestSaveAndLoadActivations(t *testing.T) {
+	rng := rand.New(rand.NewPCG(25, 26))
+	randomFunc := func(int) float64 { return rng.NormFloat64() }
+	ann := &ANN{Sequential: CreateSequential(
+		CreateDense(randomFunc, 4, 6),
+		CreateELU(.5),
+		CreateDense(randomFunc, 6, 6),
+		CreatePReLU(.3),
+		CreateDense(randomFunc, 6, 10),
+		CreateSoftmax(),
+	)}
+	ann.Layers[3].(*PReLU).Slope.Value.Data[0] = .7
+
+	buffer := &bytes.Buffer{}
+	if err := Save(buffer, ann); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elu := loaded.Layers[1].(*ActivationLayer); elu.Name != "elu" || !slices.Equal(elu.Options, []float64{.5}) {
+		t.Errorf("expected elu with alpha 0.5 but got %s %v", elu.Name, elu.Options)
+	}
+	if slope := loaded.Layers[3].(*PReLU).Slope.Value.Data[0]; slope != .7 {
+		t.Errorf("expected the learned slope 0.7 but got %f", slope)
+	}
+
+	input := randomTensor(rng, 1, 4)
+	if !slices.Equal(loaded.Forward(input).Data, ann.Forward(input).Data) {
+		t.Errorf("expected the loaded model to give the same output")
+	}
+}
@@ -0,0 +1,184 @@
+package neuron
+
+import (
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+// Conv2D slides OutputChannels kernels over images laid out as
+// channel x height x width. no activation is applied to the output
+type Conv2D struct {
+	InputChannels  int
+	InputHeight    int
+	InputWidth     int
+	OutputChannels int
+	KernelSize     int
+	Stride         int
+	Padding        int
+
+	Weights *Parameter // output channel x (input channel x kernel row x kernel column)
+	Biases  *Parameter // one per output channel
+
+	patchIdxs []int            // input index for every (output position, kernel position), -1 for padding
+	columns   []*tensor.Tensor // the patches of every sample, cached by Forward for Backward
+}
+
+func CreateConv2D(randomFunc func(int) float64, inputChannels, inputHeight, inputWidth, outputChannels, kernelSize, stride, padding int) *Conv2D {
+	if kernelSize <= 0 || stride <= 0 || padding < 0 {
+		common.PrintAndTerminate(fmt.Sprintf("invalid convolution kernel: %d stride: %d padding: %d", kernelSize, stride, padding))
+	}
+
+	conv := &Conv2D{
+		InputChannels:  inputChannels,
+		InputHeight:    inputHeight,
+		InputWidth:     inputWidth,
+		OutputChannels: outputChannels,
+		KernelSize:     kernelSize,
+		Stride:         stride,
+		Padding:        padding,
+	}
+
+	if conv.OutputHeight() <= 0 || conv.OutputWidth() <= 0 {
+		common.PrintAndTerminate(fmt.Sprintf("kernel %d does not fit input %dx%d", kernelSize, inputHeight, inputWidth))
+	}
+
+	conv.Weights = createParameter(outputChannels, conv.patchSize())
+	conv.Biases = createParameter(outputChannels)
+	conv.Weights.Penalize = true
+	conv.Initialize(common.FromRandomFunc(randomFunc), common.Constant(0))
+
+	return conv
+}
+
+// Initialize replaces the kernels and biases. every output sees patchSize
+// inputs and every input reaches up to OutputChannels x KernelSize² outputs
+func (conv *Conv2D) Initialize(weights, biases common.Initializer) {
+	fanIn := conv.patchSize()
+	fanOut := conv.OutputChannels * conv.KernelSize * conv.KernelSize
+	weights(conv.Weights.Value.Data, fanIn, fanOut)
+	biases(conv.Biases.Value.Data, fanIn, fanOut)
+}
+
+func (conv *Conv2D) OutputHeight() int {
+	return (conv.InputHeight+2*conv.Padding-conv.KernelSize)/conv.Stride + 1
+}
+
+func (conv *Conv2D) OutputWidth() int {
+	return (conv.InputWidth+2*conv.Padding-conv.KernelSize)/conv.Stride + 1
+}
+
+func (conv *Conv2D) OutputSize() int {
+	return conv.OutputChannels * conv.OutputHeight() * conv.OutputWidth()
+}
+
+func (conv *Conv2D) inputSize() int {
+	return conv.InputChannels * conv.InputHeight * conv.InputWidth
+}
+
+func (conv *Conv2D) patchSize() int {
+	return conv.InputChannels * conv.KernelSize * conv.KernelSize
+}
+
+// Forward lays every patch of a sample out as a row (im2col) so the whole
+// convolution becomes one matrix multiply with the kernels
+func (conv *Conv2D) Forward(input *tensor.Tensor) *tensor.Tensor {
+	checkFeatures(input, conv.inputSize(), "convolution")
+
+	batchSize := input.Shape[0]
+	outputArea := conv.OutputHeight() * conv.OutputWidth()
+	output := tensor.New(batchSize, conv.OutputSize())
+	conv.columns = make([]*tensor.Tensor, batchSize)
+
+	for n := range batchSize {
+		columns := conv.im2col(input.Row(n))
+		conv.columns[n] = columns
+
+		sample := tensor.MatMul(conv.Weights.Value, columns.Transpose()) // output channel x output position
+		row := output.Row(n)
+		for i, value := range sample.Data {
+			row[i] = value + conv.Biases.Value.Data[i/outputArea]
+		}
+	}
+
+	return output
+}
+
+// Backward accumulates the weight and bias gradients and returns the
+// gradient of the loss w.r.t the input of the last Forward call
+func (conv *Conv2D) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	batchSize := outputGradient.Shape[0]
+	outputArea := conv.OutputHeight() * conv.OutputWidth()
+	inputGradient := tensor.New(batchSize, conv.inputSize())
+
+	for n := range batchSize {
+		gradient := tensor.FromSlice(outputGradient.Row(n), conv.OutputChannels, outputArea)
+
+		conv.Weights.Gradient.Add(tensor.MatMul(gradient, conv.columns[n]))
+		for i, value := range gradient.Data {
+			conv.Biases.Gradient.Data[i/outputArea] += value
+		}
+
+		columnGradient := tensor.MatMul(gradient.Transpose(), conv.Weights.Value) // output position x patch
+		conv.col2im(columnGradient, inputGradient.Row(n))
+	}
+
+	return inputGradient
+}
+
+func (conv *Conv2D) Parameters() []*Parameter {
+	return []*Parameter{conv.Weights, conv.Biases}
+}
+
+func (conv *Conv2D) im2col(input []float64) *tensor.Tensor {
+	patchIdxs := conv.patchIndexes()
+	columns := tensor.New(conv.OutputHeight()*conv.OutputWidth(), conv.patchSize())
+
+	for i, inputIdx := range patchIdxs {
+		if inputIdx >= 0 {
+			columns.Data[i] = input[inputIdx]
+		}
+	}
+
+	return columns
+}
+
+func (conv *Conv2D) col2im(columns *tensor.Tensor, inputGradient []float64) {
+	for i, inputIdx := range conv.patchIndexes() {
+		if inputIdx >= 0 {
+			inputGradient[inputIdx] += columns.Data[i]
+		}
+	}
+}
+
+// maps every (output position, kernel position) pair to the input it reads.
+// positions that fall in the zero padding map to -1
+func (conv *Conv2D) patchIndexes() []int {
+	if conv.patchIdxs != nil {
+		return conv.patchIdxs
+	}
+
+	outputHeight, outputWidth := conv.OutputHeight(), conv.OutputWidth()
+	conv.patchIdxs = make([]int, 0, outputHeight*outputWidth*conv.patchSize())
+
+	for oy := range outputHeight {
+		for ox := range outputWidth {
+			for ic := range conv.InputChannels {
+				for ky := range conv.KernelSize {
+					for kx := range conv.KernelSize {
+						y := oy*conv.Stride + ky - conv.Padding
+						x := ox*conv.Stride + kx - conv.Padding
+
+						if y < 0 || y >= conv.InputHeight || x < 0 || x >= conv.InputWidth {
+							conv.patchIdxs = append(conv.patchIdxs, -1)
+						} else {
+							conv.patchIdxs = append(conv.patchIdxs, (ic*conv.InputHeight+y)*conv.InputWidth+x)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return conv.patchIdxs
+}
@@ -0,0 +1,112 @@
+package neuron
+
+import (
+	"math/rand/v2"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+	"testing"
+)
+
+func TestConv2DForwardPerformsCorrectCalculations(t *testing.T) {
+	conv := CreateConv2D(func(int) float64 { return 0 }, 1, 3, 3, 1, 2, 1, 0)
+	copy(conv.Weights.Value.Data, []float64{
+		.1, .2,
+		.3, .4,
+	})
+	conv.Biases.Value.Data[0] = .5
+
+	input := tensor.FromSlice([]float64{
+		1, 2, 3,
+		4, 5, 6,
+		7, 8, 9,
+	}, 1, 9)
+
+	expected := []float64{
+		(.1*1 + .2*2 + .3*4 + .4*5) + .5,
+		(.1*2 + .2*3 + .3*5 + .4*6) + .5,
+		(.1*4 + .2*5 + .3*7 + .4*8) + .5,
+		(.1*5 + .2*6 + .3*8 + .4*9) + .5,
+	}
+
+	actual := conv.Forward(input).Row(0)
+
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d outputs but got %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		if relativeError(expected[i], actual[i]) > 1e-12 {
+			t.Errorf("output %d: expected %f but got %f", i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestConv2DOutputSizeWithStrideAndPadding(t *testing.T) {
+	conv := CreateConv2D(func(int) float64 { return 1 }, 2, 5, 5, 3, 3, 2, 1)
+
+	if conv.OutputHeight() != 3 || conv.OutputWidth() != 3 {
+		t.Errorf("expected 3x3 output but got %dx%d", conv.OutputHeight(), conv.OutputWidth())
+	}
+	if conv.OutputSize() != 3*3*3 {
+		t.Errorf("expected output size %d but got %d", 3*3*3, conv.OutputSize())
+	}
+	if conv.Weights.Value.Len() != 3*2*3*3 {
+		t.Errorf("expected %d weights but got %d", 3*2*3*3, conv.Weights.Value.Len())
+	}
+
+	// the corner output only sees the bottom right 2x2 of the kernel because of padding
+	input := tensor.New(1, 2*5*5)
+	input.Fill(1)
+	output := conv.Forward(input)
+	if output.At(0, 0) != 2*2*2 {
+		t.Errorf("expected padded corner to sum %d inputs but got %f", 2*2*2, output.At(0, 0))
+	}
+}
+
+func TestConv2DBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	conv := CreateConv2D(func(int) float64 { return rng.NormFloat64() }, 2, 5, 4, 3, 3, 2, 1)
+	for i := range conv.Biases.Value.Data {
+		conv.Biases.Value.Data[i] = rng.NormFloat64()
+	}
+
+	checkLayerGradients(t, conv, randomTensor(rng, 2, 2*5*4), rng)
+}
+
+func TestConv2DInFrontOfANNReducesLoss(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	randomFunc := func(fanInSize int) float64 {
+		return rng.NormFloat64() / float64(fanInSize)
+	}
+	conv := CreateConv2D(randomFunc, 1, 6, 6, 2, 3, 1, 1)
+	ann := CreateANN(randomFunc, conv.OutputSize(), 1)
+
+	input := tensor.New(1, 6*6)
+	for i := range input.Data {
+		input.Data[i] = float64(i % 2)
+	}
+	expectedOneHotEncoding := make([]float64, 10)
+	expectedOneHotEncoding[7] = 1
+
+	step := func() float64 {
+		ann.SetInput(conv.Forward(input).Row(0))
+		ann.ForwardPropagation()
+		loss := common.CrossEntropyLoss(expectedOneHotEncoding, ann.OutputVector())
+
+		const learningRate = .05
+		inputGradient := ann.BackwardPropagation(expectedOneHotEncoding, learningRate)
+		conv.Backward(tensor.FromSlice(inputGradient, 1, len(inputGradient)))
+		UpdateParameters(conv.Parameters(), learningRate)
+
+		return loss
+	}
+
+	initialLoss := step()
+	finalLoss := initialLoss
+	for range 20 {
+		finalLoss = step()
+	}
+
+	if finalLoss >= initialLoss {
+		t.Errorf("expected loss to decrease from %f but was %f", initialLoss, finalLoss)
+	}
+}
@@ -0,0 +1,73 @@
+package neuron
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+// TrainingMode is implemented by layers that behave differently while
+// training than during inference, such as Dropout
+type TrainingMode interface {
+	SetTraining(training bool)
+}
+
+// Dropout zeroes every input with probability Rate while training and scales
+// the rest by 1 / (1 - Rate), so nothing needs to change during inference,
+// where it passes inputs through
+type Dropout struct {
+	Rate float64
+
+	rng      *rand.Rand
+	training bool
+	mask     *tensor.Tensor // the scale of every input in the last training Forward
+}
+
+func CreateDropout(rate float64, rng *rand.Rand) *Dropout {
+	if rate < 0 || rate >= 1 {
+		common.PrintAndTerminate(fmt.Sprintf("invalid dropout rate: %f", rate))
+	}
+
+	return &Dropout{Rate: rate, rng: rng}
+}
+
+func (dropout *Dropout) SetTraining(training bool) {
+	dropout.training = training
+}
+
+func (dropout *Dropout) Forward(input *tensor.Tensor) *tensor.Tensor {
+	if !dropout.training {
+		dropout.mask = nil
+		return input
+	}
+
+	dropout.mask = tensor.New(input.Shape...)
+	output := tensor.New(input.Shape...)
+	scale := 1 / (1 - dropout.Rate)
+	for i, x := range input.Data {
+		if dropout.rng.Float64() >= dropout.Rate {
+			dropout.mask.Data[i] = scale
+			output.Data[i] = x * scale
+		}
+	}
+
+	return output
+}
+
+func (dropout *Dropout) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	if dropout.mask == nil {
+		return outputGradient
+	}
+
+	inputGradient := tensor.New(outputGradient.Shape...)
+	for i, gradient := range outputGradient.Data {
+		inputGradient.Data[i] = gradient * dropout.mask.Data[i]
+	}
+
+	return inputGradient
+}
+
+func (dropout *Dropout) Parameters() []*Parameter {
+	return nil
+}
@@ -0,0 +1,137 @@
+package neuron
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+	"testing"
+)
+
+func TestDropoutPassesInputsThroughDuringInference(t *testing.T) {
+	dropout := CreateDropout(.5, rand.New(rand.NewPCG(29, 30)))
+	input := tensor.FromSlice([]float64{1, 2, 3}, 1, 3)
+
+	if output := dropout.Forward(input); !slices.Equal(output.Data, input.Data) {
+		t.Errorf("expected %v but got %v", input.Data, output.Data)
+	}
+	if gradient := dropout.Backward(input); !slices.Equal(gradient.Data, input.Data) {
+		t.Errorf("expected gradient %v but got %v", input.Data, gradient.Data)
+	}
+}
+
+func TestDropoutScalesTheInputsItKeeps(t *testing.T) {
+	dropout := CreateDropout(.25, rand.New(rand.NewPCG(31, 32)))
+	dropout.SetTraining(true)
+
+	input := tensor.New(100, 100)
+	input.Fill(1)
+	output := dropout.Forward(input)
+
+	dropped, sum := 0, float64(0)
+	for _, value := range output.Data {
+		switch value {
+		case 0:
+			dropped++
+		case 1 / .75:
+		default:
+			t.Fatalf("expected inputs to be dropped or scaled by 1/0.75 but got %f", value)
+		}
+		sum += value
+	}
+
+	if math.Abs(float64(dropped)/float64(input.Len())-.25) > .02 {
+		t.Errorf("expected about 25%% of the inputs to be dropped but got %d of %d", dropped, input.Len())
+	}
+	if math.Abs(sum/float64(input.Len())-1) > .03 {
+		t.Errorf("expected the mean to stay about 1 but got %f", sum/float64(input.Len()))
+	}
+}
+
+func TestDropoutBackwardUsesTheMask(t *testing.T) {
+	dropout := CreateDropout(.5, rand.New(rand.NewPCG(33, 34)))
+	dropout.SetTraining(true)
+
+	output := dropout.Forward(tensor.FromSlice([]float64{1, 1, 1, 1, 1, 1, 1, 1}, 1, 8))
+	gradient := dropout.Backward(tensor.FromSlice([]float64{1, 1, 1, 1, 1, 1, 1, 1}, 1, 8))
+
+	if !slices.Equal(output.Data, gradient.Data) {
+		t.Errorf("expected dropped inputs to get no gradient, output %v gradient %v", output.Data, gradient.Data)
+	}
+}
+
+func TestSetTrainingReachesNestedLayers(t *testing.T) {
+	dropout := CreateDropout(.5, rand.New(rand.NewPCG(35, 36)))
+	model := CreateSequential(CreateReLU(), CreateSequential(dropout))
+
+	model.SetTraining(true)
+	if !dropout.training || !model.Training() {
+		t.Errorf("expected nested dropout to be in training mode")
+	}
+
+	model.SetTraining(false)
+	if dropout.training || model.Training() {
+		t.Errorf("expected nested dropout to be in inference mode")
+	}
+}
+
+func TestTrainBatchOnlyDropsWhileTraining(t *testing.T) {
+	rng := rand.New(rand.NewPCG(37, 38))
+	ann := CreateANN(func(int) float64 { return rng.NormFloat64() }, 8, 1, WithDropout(.5, rng))
+
+	inputs := randomTensor(rng, 4, 8)
+	expected := tensor.New(4, 10)
+	expected.Fill(.1)
+
+	ann.TrainBatch(inputs, expected, .1)
+	if ann.Training() {
+		t.Errorf("expected TrainBatch to go back to inference mode")
+	}
+
+	first, _ := ann.Evaluate(inputs, expected)
+	second, _ := ann.Evaluate(inputs, expected)
+	if first != second {
+		t.Errorf("expected evaluation to be deterministic but got %f and %f", first, second)
+	}
+}
+
+func TestSaveAndLoadDropout(t *testing.T) {
+	ann := CreateANN(func(int) float64 { return .1 }, 8, 1, WithDropout(.3, rand.New(rand.NewPCG(39, 40))))
+
+	buffer := &bytes.Buffer{}
+	if err := Save(buffer, &ann); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dropout, ok := loaded.Layers[2].(*Dropout)
+	if !ok || dropout.Rate != .3 {
+		t.Errorf("expected dropout with rate 0.3 after the hidden activation but got %T", loaded.Layers[2])
+	}
+}
+
+// draws the same mask on every forward pass so finite differences see one function
+type fixedMaskDropout struct {
+	*Dropout
+	source *rand.PCG
+}
+
+func (dropout fixedMaskDropout) Forward(input *tensor.Tensor) *tensor.Tensor {
+	dropout.source.Seed(35, 36)
+	return dropout.Dropout.Forward(input)
+}
+
+func TestDropoutBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(37, 38))
+	source := rand.NewPCG(35, 36)
+	dropout := fixedMaskDropout{CreateDropout(.5, rand.New(source)), source}
+
+	for _, training := range []bool{false, true} {
+		dropout.SetTraining(training)
+		checkLayerGradients(t, dropout, randomTensor(rng, 3, 8), rng)
+	}
+}
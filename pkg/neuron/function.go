@@ -0,0 +1,57 @@
+package neuron
+
+import (
+	"ocr_cnn/pkg/autodiff"
+	"ocr_cnn/pkg/tensor"
+)
+
+// Function is a layer written only as its forward computation. Forward
+// records Apply on a new tape and Backward replays the tape in reverse, so
+// the gradients of any composition of autodiff operations come for free.
+// functions cannot be saved to a model file
+type Function struct {
+	Params []*Parameter
+	Apply  func(input *autodiff.Variable, parameters []*autodiff.Variable) *autodiff.Variable
+
+	tape   *autodiff.Tape
+	input  *autodiff.Variable
+	output *autodiff.Variable
+}
+
+func CreateFunction(apply func(input *autodiff.Variable, parameters []*autodiff.Variable) *autodiff.Variable, parameters ...*Parameter) *Function {
+	return &Function{Params: parameters, Apply: apply}
+}
+
+func (function *Function) Forward(input *tensor.Tensor) *tensor.Tensor {
+	function.tape = autodiff.CreateTape()
+	function.input = function.tape.Variable(input)
+
+	parameters := make([]*autodiff.Variable, len(function.Params))
+	for i, parameter := range function.Params {
+		parameters[i] = function.tape.Parameter(parameter.Value, parameter.Gradient)
+	}
+
+	function.output = function.Apply(function.input, parameters)
+	return function.output.Value
+}
+
+// Backward accumulates straight into the gradients of Params
+func (function *Function) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	function.tape.BackwardFrom(function.output, outputGradient)
+	return function.input.Gradient
+}
+
+func (function *Function) Parameters() []*Parameter {
+	return function.Params
+}
+
+// CreateDenseFunction is a Dense layer defined through autodiff, with the
+// same weight layout and initialization
+func CreateDenseFunction(randomFunc func(int) float64, inputSize, outputSize int) *Function {
+	dense := CreateDense(randomFunc, inputSize, outputSize)
+
+	return CreateFunction(func(input *autodiff.Variable, parameters []*autodiff.Variable) *autodiff.Variable {
+		weights, biases := parameters[0], parameters[1]
+		return autodiff.AddBias(autodiff.MatMul(input, autodiff.Transpose(weights)), biases)
+	}, dense.Weights, dense.Biases)
+}
@@ -0,0 +1,100 @@
+package neuron
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"ocr_cnn/pkg/autodiff"
+	"ocr_cnn/pkg/tensor"
+	"testing"
+)
+
+func TestDenseFunctionMatchesDense(t *testing.T) {
+	rng := rand.New(rand.NewPCG(71, 72))
+	dense := CreateDense(func(int) float64 { return rng.NormFloat64() }, 5, 3)
+	function := CreateDenseFunction(func(int) float64 { return 0 }, 5, 3)
+	copy(function.Params[0].Value.Data, dense.Weights.Value.Data)
+	copy(function.Params[1].Value.Data, dense.Biases.Value.Data)
+
+	input := randomTensor(rng, 2, 5)
+	outputGradient := randomTensor(rng, 2, 3)
+
+	expected, actual := dense.Forward(input), function.Forward(input)
+	for i := range expected.Data {
+		if relativeError(expected.Data[i], actual.Data[i]) > 1e-12 {
+			t.Fatalf("output %d: expected %f but got %f", i, expected.Data[i], actual.Data[i])
+		}
+	}
+
+	expected, actual = dense.Backward(outputGradient), function.Backward(outputGradient)
+	for i := range expected.Data {
+		if relativeError(expected.Data[i], actual.Data[i]) > 1e-12 {
+			t.Fatalf("input gradient %d: expected %f but got %f", i, expected.Data[i], actual.Data[i])
+		}
+	}
+	for p, parameter := range dense.Parameters() {
+		for i, gradient := range parameter.Gradient.Data {
+			if relativeError(gradient, function.Params[p].Gradient.Data[i]) > 1e-12 {
+				t.Fatalf("parameter %d gradient %d: expected %f but got %f", p, i, gradient, function.Params[p].Gradient.Data[i])
+			}
+		}
+	}
+}
+
+func TestFunctionBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(73, 74))
+	kernels, biases := createParameter(2, 1*3*3), createParameter(2)
+	randomizeParameters(CreateFunction(nil, kernels, biases), rng)
+
+	// a convolution whose features are gated by their own sigmoid
+	function := CreateFunction(func(input *autodiff.Variable, parameters []*autodiff.Variable) *autodiff.Variable {
+		features := autodiff.Conv2D(input, parameters[0], parameters[1], autodiff.Convolution{
+			Channels: 1, Height: 4, Width: 4, KernelSize: 3, Stride: 1, Padding: 1,
+		})
+		return autodiff.Mul(features, autodiff.Sigmoid(features))
+	}, kernels, biases)
+
+	checkLayerGradients(t, function, randomTensor(rng, 2, 16), rng)
+}
+
+func TestModelOfFunctionsLearns(t *testing.T) {
+	rng := rand.New(rand.NewPCG(75, 76))
+	randomFunc := func(fanInSize int) float64 {
+		return rng.NormFloat64() / float64(fanInSize)
+	}
+	model := CreateSequential(
+		CreateDenseFunction(randomFunc, 16, 8),
+		CreateFunction(func(input *autodiff.Variable, _ []*autodiff.Variable) *autodiff.Variable {
+			return autodiff.Tanh(input)
+		}),
+		CreateDenseFunction(randomFunc, 8, 10),
+		CreateSoftmax(),
+	)
+	model.Optimizer = CreateAdam(.9, .999, 1e-8)
+
+	input := randomTensor(rng, 4, 16)
+	expectedOneHotEncodings := tensor.New(4, 10)
+	for n := range 4 {
+		expectedOneHotEncodings.Set(1, n, n)
+	}
+
+	if check := CheckModelGradients(model, input, expectedOneHotEncodings); check.MaxRelativeError > 1e-5 {
+		t.Error(check)
+	}
+
+	ann := ANN{Sequential: model}
+	initialLoss, _ := ann.TrainBatch(input, expectedOneHotEncodings, .01)
+	finalLoss := initialLoss
+	for range 50 {
+		finalLoss, _ = ann.TrainBatch(input, expectedOneHotEncodings, .01)
+	}
+	if finalLoss >= initialLoss/2 {
+		t.Errorf("expected loss to halve from %f but was %f", initialLoss, finalLoss)
+	}
+}
+
+func TestSaveRejectsFunctions(t *testing.T) {
+	ann := ANN{Sequential: CreateSequential(CreateDenseFunction(func(int) float64 { return 0 }, 2, 2), CreateSoftmax())}
+	if err := Save(&bytes.Buffer{}, &ann); err == nil {
+		t.Errorf("expected an error saving a function layer")
+	}
+}
@@ -0,0 +1,139 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+const (
+	gradientCheckEpsilon = 1e-5 // step of the central finite differences
+	// gradients smaller than this are compared by their absolute difference,
+	// as the rounding of the loss dominates them
+	gradientCheckFloor = 1e-4
+)
+
+// GradientCheck compares analytic gradients with central finite differences
+// and keeps the value where they disagree the most
+type GradientCheck struct {
+	MaxRelativeError float64
+	Parameter        int // index into Parameters of the worst value, -1 for the input
+	Index            int
+	Analytic         float64
+	Numerical        float64
+}
+
+func (check GradientCheck) String() string {
+	name := fmt.Sprintf("parameter %d", check.Parameter)
+	if check.Parameter < 0 {
+		name = "input"
+	}
+	return fmt.Sprintf("max relative error %e at %s value %d: analytic %e numerical %e",
+		check.MaxRelativeError, name, check.Index, check.Analytic, check.Numerical)
+}
+
+// CheckLayerGradients checks Backward of a layer against a loss that is the
+// sum of its outputs weighted by outputWeights, which has the shape of the output
+func CheckLayerGradients(layer Layer, input, outputWeights *tensor.Tensor) GradientCheck {
+	loss := func() float64 {
+		sum := float64(0)
+		for i, value := range layer.Forward(input).Data {
+			sum += value * outputWeights.Data[i]
+		}
+		return sum
+	}
+
+	backward := func() *tensor.Tensor {
+		layer.Forward(input)
+		return layer.Backward(outputWeights)
+	}
+
+	return checkGradients(layer.Parameters(), layerBuffers(layer), input, loss, backward)
+}
+
+// CheckModelGradients checks AccumulateGradients against the cross entropy
+// loss of the model summed over the batch. regularization penalties are not
+// part of the check
+func CheckModelGradients(model *Sequential, inputs, expectedOneHotEncodings *tensor.Tensor) GradientCheck {
+	loss := func() float64 {
+		output := model.Forward(inputs)
+		sum := float64(0)
+		for n := range output.Shape[0] {
+			sum += common.CrossEntropyLoss(expectedOneHotEncodings.Row(n), output.Row(n))
+		}
+		return sum
+	}
+
+	backward := func() *tensor.Tensor {
+		model.Forward(inputs)
+		return model.AccumulateGradients(expectedOneHotEncodings)
+	}
+
+	return checkGradients(model.Parameters(), layerBuffers(model), inputs, loss, backward)
+}
+
+// layerBuffers are the buffers of a layer and of every layer nested in it
+func layerBuffers(layer Layer) []*tensor.Tensor {
+	if sequential, ok := layer.(*Sequential); ok {
+		buffers := []*tensor.Tensor{}
+		for _, nested := range sequential.Layers {
+			buffers = append(buffers, layerBuffers(nested)...)
+		}
+		return buffers
+	}
+	if buffered, ok := layer.(BufferedLayer); ok {
+		return buffered.Buffers()
+	}
+	return nil
+}
+
+// checkGradients nudges every parameter and input value both ways. every
+// forward pass in training mode moves the running statistics of batch norm,
+// so the buffers are put back afterwards, and gradients start and end at zero
+func checkGradients(parameters []*Parameter, buffers []*tensor.Tensor, input *tensor.Tensor, loss func() float64, backward func() *tensor.Tensor) GradientCheck {
+	originalBuffers := make([]*tensor.Tensor, len(buffers))
+	for i, buffer := range buffers {
+		originalBuffers[i] = buffer.Clone()
+	}
+	defer func() {
+		for i, buffer := range buffers {
+			copy(buffer.Data, originalBuffers[i].Data)
+		}
+	}()
+
+	for _, parameter := range parameters {
+		parameter.Gradient.Fill(0)
+	}
+	inputGradient := backward()
+
+	numericalGradient := func(value *float64) float64 {
+		original := *value
+		*value = original + gradientCheckEpsilon
+		lossPlus := loss()
+		*value = original - gradientCheckEpsilon
+		lossMinus := loss()
+		*value = original
+		return (lossPlus - lossMinus) / (2 * gradientCheckEpsilon)
+	}
+
+	check := GradientCheck{Parameter: -1}
+	compare := func(p, i int, analytic, numerical float64) {
+		err := math.Abs(analytic-numerical) / math.Max(math.Abs(analytic)+math.Abs(numerical), gradientCheckFloor)
+		if err > check.MaxRelativeError {
+			check = GradientCheck{MaxRelativeError: err, Parameter: p, Index: i, Analytic: analytic, Numerical: numerical}
+		}
+	}
+
+	for p, parameter := range parameters {
+		for i := range parameter.Value.Data {
+			compare(p, i, parameter.Gradient.Data[i], numericalGradient(&parameter.Value.Data[i]))
+		}
+		parameter.Gradient.Fill(0)
+	}
+	for i := range input.Data {
+		compare(-1, i, inputGradient.Data[i], numericalGradient(&input.Data[i]))
+	}
+
+	return check
+}
@@ -0,0 +1,118 @@
+package neuron
+
+import (
+	"math"
+	"math/rand/v2"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+	"testing"
+)
+
+// relativeError of two values, measured against their magnitude unless both are tiny
+func relativeError(a, b float64) float64 {
+	denominator := math.Max(math.Abs(a)+math.Abs(b), 1e-8)
+	return math.Abs(a-b) / denominator
+}
+
+func TestCheckModelGradientsOfANNForEveryHiddenLayer(t *testing.T) {
+	rng := rand.New(rand.NewPCG(61, 62))
+
+	expectedOneHotEncodings := tensor.New(3, 10)
+	for n := range 3 {
+		expectedOneHotEncodings.Set(1, n, rng.IntN(10))
+	}
+
+	check := func(t *testing.T, training bool, options ...ANNOption) {
+		t.Helper()
+
+		options = append(options, WithInitializers(common.XavierNormal(rng), common.TruncatedNormal(rng, 0, .1)))
+		ann := CreateANN(nil, 16, 2, options...)
+		ann.SetTraining(training)
+
+		if check := CheckModelGradients(ann.Sequential, randomTensor(rng, 3, 16), expectedOneHotEncodings); check.MaxRelativeError > 1e-5 {
+			t.Error(check)
+		}
+	}
+
+	for _, name := range ActivationNames() {
+		t.Run(name, func(t *testing.T) {
+			check(t, false, WithHiddenActivations(func() Layer {
+				activation, _ := CreateActivation(name)
+				return activation
+			}))
+		})
+	}
+	t.Run("batchnorm", func(t *testing.T) {
+		check(t, true, WithNormalization(func(size int) Layer { return CreateBatchNorm(size, .1, 1e-5) }))
+	})
+	t.Run("layernorm", func(t *testing.T) {
+		check(t, true, WithNormalization(func(size int) Layer { return CreateLayerNorm(size, 1e-5) }))
+	})
+	t.Run("dropout during inference", func(t *testing.T) {
+		check(t, false, WithDropout(.5, rng))
+	})
+}
+
+func TestCheckModelGradientsWithoutASoftmaxOutput(t *testing.T) {
+	rng := rand.New(rand.NewPCG(63, 64))
+	dense := CreateDense(func(int) float64 { return rng.NormFloat64() }, 4, 3)
+	model := CreateSequential(dense, CreateSigmoid())
+
+	expected := tensor.FromSlice([]float64{
+		1, 0, 0,
+		0, .5, .5,
+	}, 2, 3)
+
+	if check := CheckModelGradients(model, randomTensor(rng, 2, 4), expected); check.MaxRelativeError > 1e-5 {
+		t.Error(check)
+	}
+}
+
+// doubles the gradient it should pass back
+type brokenLayer struct{ ActivationLayer }
+
+func (layer *brokenLayer) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	inputGradient := layer.ActivationLayer.Backward(outputGradient)
+	inputGradient.Data[1] *= 2
+	return inputGradient
+}
+
+func TestCheckLayerGradientsFindsTheWrongGradient(t *testing.T) {
+	rng := rand.New(rand.NewPCG(65, 66))
+	layer := &brokenLayer{*CreateSigmoid()}
+
+	check := CheckLayerGradients(layer, randomTensor(rng, 1, 3), randomTensor(rng, 1, 3))
+	if check.Parameter != -1 || check.Index != 1 || check.MaxRelativeError < .3 {
+		t.Errorf("expected input value 1 to be off by a third but got %s", check)
+	}
+}
+
+func TestCheckModelGradientsLeavesGradientsAtZero(t *testing.T) {
+	rng := rand.New(rand.NewPCG(67, 68))
+	model := CreateSequential(CreateDense(func(int) float64 { return rng.NormFloat64() }, 4, 3), CreateSoftmax())
+
+	CheckModelGradients(model, randomTensor(rng, 2, 4), tensor.FromSlice([]float64{1, 0, 0, 0, 0, 1}, 2, 3))
+
+	for p, parameter := range model.Parameters() {
+		for _, gradient := range parameter.Gradient.Data {
+			if gradient != 0 {
+				t.Fatalf("expected parameter %d gradient to be reset but got %v", p, parameter.Gradient.Data)
+			}
+		}
+	}
+}
+
+func TestCheckModelGradientsLeavesRunningStatisticsAsTheyWere(t *testing.T) {
+	rng := rand.New(rand.NewPCG(69, 70))
+	batchNorm := CreateBatchNorm(3, .1, 1e-5)
+	model := CreateSequential(CreateDense(func(int) float64 { return rng.NormFloat64() }, 4, 3), batchNorm, CreateSoftmax())
+	model.SetTraining(true)
+
+	CheckModelGradients(model, randomTensor(rng, 2, 4), tensor.FromSlice([]float64{1, 0, 0, 0, 0, 1}, 2, 3))
+
+	for i := range 3 {
+		if batchNorm.RunningMean.Data[i] != 0 || batchNorm.RunningVariance.Data[i] != 1 {
+			t.Fatalf("expected running mean 0 and variance 1 but got %v and %v", batchNorm.RunningMean.Data, batchNorm.RunningVariance.Data)
+		}
+	}
+}
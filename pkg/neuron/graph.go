@@ -0,0 +1,280 @@
+package neuron
+
+import (
+	"fmt"
+	"image"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+type Weight struct {
+	Value float64
+}
+
+type Edge struct {
+	Weight *Weight
+	Neuron *Neuron
+}
+
+type Neuron struct {
+	Input      []*Edge
+	Output     []*Edge
+	Bias       float64
+	Activation float64
+}
+
+// Graph is a neuron and edge view of a dense network with activation hidden
+// layers and a softmax output. it is built from an ANN with ANN.Graph, and its
+// propagation methods run the tensor implementation and copy the results back
+type Graph struct {
+	InputLayer  []*Neuron
+	OutputLayer []*Neuron
+	Activations []*ActivationLayer // of every hidden layer from the input, ReLU for those left out
+}
+
+// Graph copies the weights, biases and last activations of a dense ANN into
+// neurons and edges. changes to the view do not reach the ANN
+func (ann *ANN) Graph() Graph {
+	var lastLayer []*Neuron
+	var firstLayer []*Neuron
+	activationLayers := []*ActivationLayer{}
+
+	for l, layer := range ann.Layers {
+		var dense *Dense
+		switch layer := layer.(type) {
+		case *Dense:
+			dense = layer
+		case *ActivationLayer:
+			activationLayers = append(activationLayers, layer)
+			continue
+		case *Softmax, *Dropout:
+			continue
+		default:
+			common.PrintAndTerminate(fmt.Sprintf("graph view only supports dense layers and activations without parameters, got %T", layer))
+		}
+
+		if firstLayer == nil {
+			firstLayer = createNeurons(dense.InputSize, ann.Input, nil)
+			lastLayer = firstLayer
+		}
+
+		var activations *tensor.Tensor
+		if l+1 < len(ann.Layers) {
+			switch next := ann.Layers[l+1].(type) {
+			case *ActivationLayer:
+				activations = next.output
+			case *Softmax:
+				activations = next.output
+			}
+		}
+		currentLayer := createNeurons(dense.OutputSize, activations, dense.Biases.Value)
+
+		for i, lastNeuron := range lastLayer { // connect the graph bipartite
+			for j, currentNeuron := range currentLayer {
+				weight := Weight{Value: dense.Weights.Value.At(j, i)}
+				lastNeuron.Output = append(lastNeuron.Output, &Edge{
+					Neuron: currentNeuron,
+					Weight: &weight,
+				})
+				currentNeuron.Input = append(currentNeuron.Input, &Edge{
+					Neuron: lastNeuron,
+					Weight: &weight,
+				})
+			}
+		}
+		lastLayer = currentLayer
+	}
+
+	return Graph{
+		InputLayer:  firstLayer,
+		OutputLayer: lastLayer,
+		Activations: activationLayers,
+	}
+}
+
+func createNeurons(size int, activations *tensor.Tensor, biases *tensor.Tensor) []*Neuron {
+	neurons := make([]*Neuron, size)
+	for i := range neurons {
+		neurons[i] = &Neuron{}
+		if activations != nil {
+			neurons[i].Activation = activations.Data[i]
+		}
+		if biases != nil {
+			neurons[i].Bias = biases.Data[i]
+		}
+	}
+	return neurons
+}
+
+func (graph *Graph) ForwardPropagation() []float64 {
+	ann, layers := graph.compile()
+	logits := ann.ForwardPropagation()
+
+	for l, layer := range ann.Layers {
+		var activations *tensor.Tensor
+		switch layer := layer.(type) {
+		case *ActivationLayer:
+			activations = layer.output
+		case *Softmax:
+			activations = layer.output
+		default:
+			continue
+		}
+
+		neurons := layers[(l+1)/2]
+		for i, neuron := range neurons {
+			neuron.Activation = activations.Data[i]
+		}
+	}
+
+	return logits
+}
+
+// BackwardPropagation uses the activations already stored in the neurons, as
+// left by the last ForwardPropagation
+func (graph *Graph) BackwardPropagation(expectedOneHotEncoding []float64, learningRate float64) []float64 {
+	ann, layers := graph.compile()
+
+	{ // restore what the forward pass would have cached
+		var preActivations *tensor.Tensor
+		for l, layer := range ann.Layers {
+			neurons := layers[(l+1)/2]
+			activations := tensor.FromSlice(outputToVector(neurons), 1, len(neurons))
+
+			switch layer := layer.(type) {
+			case *Dense:
+				preActivations = layer.Forward(activations) // derivatives are taken of what went into the activation
+			case *ActivationLayer:
+				layer.input = preActivations
+				layer.output = activations
+			case *Softmax:
+				layer.output = activations
+				ann.Output = activations
+				ann.Sequential.output = activations
+			}
+		}
+	}
+
+	inputGradient := ann.BackwardPropagation(expectedOneHotEncoding, learningRate)
+
+	for l, layer := range ann.Layers {
+		dense, ok := layer.(*Dense)
+		if !ok {
+			continue
+		}
+
+		previousLayer := layers[l/2]
+		inputIdxs := map[*Neuron]int{}
+		for i, neuron := range previousLayer {
+			inputIdxs[neuron] = i
+		}
+
+		for j, neuron := range layers[l/2+1] {
+			for _, inputEdge := range neuron.Input {
+				inputEdge.Weight.Value = dense.Weights.Value.At(j, inputIdxs[inputEdge.Neuron])
+			}
+			neuron.Bias = dense.Biases.Value.Data[j]
+		}
+	}
+
+	return inputGradient
+}
+
+func (graph *Graph) InputEncoding(img image.Image) {
+	for i, value := range EncodeImage(img) {
+		graph.InputLayer[i].Activation = value
+	}
+}
+
+// compile copies the graph into an ANN of alternating dense and activation
+// layers, returning the neuron layers that line up with every dense layer.
+// every activation is a copy, so the compiled network keeps its own caches
+func (graph *Graph) compile() (ANN, [][]*Neuron) {
+	layers := graph.layers()
+
+	sequentialLayers := []Layer{}
+	for l := 1; l < len(layers); l++ {
+		previousLayer, currentLayer := layers[l-1], layers[l]
+
+		inputIdxs := map[*Neuron]int{}
+		for i, neuron := range previousLayer {
+			inputIdxs[neuron] = i
+		}
+
+		dense := CreateDense(func(int) float64 { return 0 }, len(previousLayer), len(currentLayer))
+		for j, neuron := range currentLayer {
+			for _, inputEdge := range neuron.Input {
+				dense.Weights.Value.Set(inputEdge.Weight.Value, j, inputIdxs[inputEdge.Neuron])
+			}
+			dense.Biases.Value.Data[j] = neuron.Bias
+		}
+
+		sequentialLayers = append(sequentialLayers, dense)
+		if l < len(layers)-1 {
+			activation := CreateReLU()
+			if l-1 < len(graph.Activations) {
+				original := graph.Activations[l-1]
+				activation = &ActivationLayer{Name: original.Name, Options: original.Options, Function: original.Function, Derivative: original.Derivative}
+			}
+			sequentialLayers = append(sequentialLayers, activation)
+		} else {
+			sequentialLayers = append(sequentialLayers, CreateSoftmax())
+		}
+	}
+
+	ann := ANN{Sequential: CreateSequential(sequentialLayers...)}
+	ann.SetInput(outputToVector(graph.InputLayer))
+
+	return ann, layers
+}
+
+// layers of the graph from input to output. hidden neurons are ordered by the
+// first edge that reaches them
+func (graph *Graph) layers() [][]*Neuron {
+	layers := [][]*Neuron{graph.InputLayer}
+
+	currentLayer := graph.InputLayer
+	for {
+		nextLayer := []*Neuron{}
+		seen := map[*Neuron]bool{}
+		isOutputLayer := true
+		for _, node := range currentLayer {
+			for _, outputEdge := range node.Output {
+				if !seen[outputEdge.Neuron] {
+					seen[outputEdge.Neuron] = true
+					nextLayer = append(nextLayer, outputEdge.Neuron)
+					isOutputLayer = isOutputLayer && len(outputEdge.Neuron.Output) == 0
+				}
+			}
+		}
+
+		if isOutputLayer {
+			return append(layers, graph.OutputLayer)
+		}
+
+		layers = append(layers, nextLayer)
+		currentLayer = nextLayer
+	}
+}
+
+// Print writes one line per layer, with neurons in the order of Graph.layers
+func (graph *Graph) Print(bindFunc func(string)) {
+	for _, layer := range graph.layers() {
+		currentLayerString := ""
+		for _, node := range layer {
+			currentLayerString += fmt.Sprintf("Neuron(%f) | ", node.Activation)
+		}
+
+		bindFunc(currentLayerString)
+	}
+}
+
+func outputToVector(neuron []*Neuron) []float64 {
+	vector := make([]float64, len(neuron))
+
+	for i := range len(neuron) {
+		vector[i] = neuron[i].Activation
+	}
+
+	return vector
+}
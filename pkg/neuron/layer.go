@@ -0,0 +1,202 @@
+package neuron
+
+import (
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+// Layer is a single stage of a Sequential model. inputs and outputs are
+// batch x features tensors with one sample per row. Forward caches whatever
+// Backward needs, and Backward accumulates into the gradients of Parameters
+// before returning the gradient of the loss w.r.t the input of the last Forward
+type Layer interface {
+	Forward(input *tensor.Tensor) *tensor.Tensor
+	Backward(outputGradient *tensor.Tensor) *tensor.Tensor
+	Parameters() []*Parameter
+}
+
+// BufferedLayer is implemented by layers that keep tensors which are saved
+// with the model but not learned from gradients, such as running statistics
+type BufferedLayer interface {
+	Buffers() []*tensor.Tensor
+}
+
+// Parameter is a learnable tensor together with the gradient accumulated for it
+type Parameter struct {
+	Value    *tensor.Tensor
+	Gradient *tensor.Tensor
+	Penalize bool // whether L1 and L2 penalties apply, true for weights but not biases
+}
+
+func createParameter(shape ...int) *Parameter {
+	return &Parameter{
+		Value:    tensor.New(shape...),
+		Gradient: tensor.New(shape...),
+	}
+}
+
+// UpdateParameters applies the accumulated gradients with plain SGD and resets them
+func UpdateParameters(parameters []*Parameter, learningRate float64) {
+	for _, parameter := range parameters {
+		for i, gradient := range parameter.Gradient.Data {
+			parameter.Value.Data[i] -= learningRate * gradient
+			parameter.Gradient.Data[i] = 0
+		}
+	}
+}
+
+func checkFeatures(input *tensor.Tensor, features int, layerName string) {
+	if len(input.Shape) != 2 || input.Shape[1] != features {
+		common.PrintAndTerminate(fmt.Sprintf("input of shape %v does not match %s layer of size %d", input.Shape, layerName, features))
+	}
+}
+
+// Dense is a bipartite layer: every input is connected to every output
+type Dense struct {
+	InputSize  int
+	OutputSize int
+	Weights    *Parameter // output x input
+	Biases     *Parameter
+
+	input *tensor.Tensor
+}
+
+func CreateDense(randomFunc func(int) float64, inputSize, outputSize int) *Dense {
+	dense := &Dense{
+		InputSize:  inputSize,
+		OutputSize: outputSize,
+		Weights:    createParameter(outputSize, inputSize),
+		Biases:     createParameter(outputSize),
+	}
+	dense.Weights.Penalize = true
+	dense.Initialize(common.FromRandomFunc(randomFunc), common.Constant(0))
+
+	return dense
+}
+
+// Initialize replaces the weights and biases
+func (dense *Dense) Initialize(weights, biases common.Initializer) {
+	weights(dense.Weights.Value.Data, dense.InputSize, dense.OutputSize)
+	biases(dense.Biases.Value.Data, dense.InputSize, dense.OutputSize)
+}
+
+func (dense *Dense) Forward(input *tensor.Tensor) *tensor.Tensor {
+	checkFeatures(input, dense.InputSize, "dense")
+	dense.input = input
+
+	output := tensor.MatMul(input, dense.Weights.Value.Transpose())
+	for n := range output.Shape[0] {
+		row := output.Row(n)
+		for j, bias := range dense.Biases.Value.Data {
+			row[j] += bias
+		}
+	}
+
+	return output
+}
+
+func (dense *Dense) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	dense.Weights.Gradient.Add(tensor.MatMul(outputGradient.Transpose(), dense.input))
+	for n := range outputGradient.Shape[0] {
+		for j, delta := range outputGradient.Row(n) {
+			dense.Biases.Gradient.Data[j] += delta
+		}
+	}
+
+	return tensor.MatMul(outputGradient, dense.Weights.Value)
+}
+
+func (dense *Dense) Parameters() []*Parameter {
+	return []*Parameter{dense.Weights, dense.Biases}
+}
+
+// ActivationLayer applies Function to every input. Derivative is evaluated
+// on the same input during Backward
+type ActivationLayer struct {
+	Name       string
+	Options    []float64 // settings Function was created with, such as the slope of a leaky ReLU
+	Function   func(float64) float64
+	Derivative func(float64) float64
+
+	input  *tensor.Tensor
+	output *tensor.Tensor
+}
+
+func CreateReLU() *ActivationLayer {
+	return &ActivationLayer{Name: "relu", Function: common.ReLU, Derivative: common.ReLUDerivative}
+}
+
+func CreateIdentity() *ActivationLayer {
+	return &ActivationLayer{
+		Name:       "identity",
+		Function:   func(x float64) float64 { return x },
+		Derivative: func(float64) float64 { return 1 },
+	}
+}
+
+func (activation *ActivationLayer) Forward(input *tensor.Tensor) *tensor.Tensor {
+	activation.input = input
+	activation.output = tensor.New(input.Shape...)
+
+	for i, x := range input.Data {
+		activation.output.Data[i] = activation.Function(x)
+	}
+
+	return activation.output
+}
+
+func (activation *ActivationLayer) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	inputGradient := tensor.New(outputGradient.Shape...)
+
+	for i, gradient := range outputGradient.Data {
+		inputGradient.Data[i] = gradient * activation.Derivative(activation.input.Data[i])
+	}
+
+	return inputGradient
+}
+
+func (activation *ActivationLayer) Parameters() []*Parameter {
+	return nil
+}
+
+// Softmax turns every row of logits into probabilities
+type Softmax struct {
+	input  *tensor.Tensor
+	output *tensor.Tensor
+}
+
+func CreateSoftmax() *Softmax {
+	return &Softmax{}
+}
+
+func (softmax *Softmax) Forward(input *tensor.Tensor) *tensor.Tensor {
+	softmax.input = input
+	softmax.output = tensor.New(input.Shape...)
+
+	for n := range input.Shape[0] {
+		copy(softmax.output.Row(n), common.SoftMax(input.Row(n)))
+	}
+
+	return softmax.output
+}
+
+func (softmax *Softmax) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	inputGradient := tensor.New(outputGradient.Shape...)
+
+	for n := range outputGradient.Shape[0] {
+		jacobian := common.SoftmaxPartialDerivitive(softmax.output.Row(n))
+		inputRow := inputGradient.Row(n)
+		for i := range inputRow {
+			for j, gradient := range outputGradient.Row(n) {
+				inputRow[i] += jacobian[j][i] * gradient
+			}
+		}
+	}
+
+	return inputGradient
+}
+
+func (softmax *Softmax) Parameters() []*Parameter {
+	return nil
+}
@@ -0,0 +1,134 @@
+package neuron
+
+import (
+	"math/rand/v2"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+	"testing"
+)
+
+func TestDenseForwardPerformsCorrectCalculations(t *testing.T) {
+	dense := CreateDense(func(int) float64 { return 0 }, 2, 2)
+	copy(dense.Weights.Value.Data, []float64{
+		.1, .2, // output A
+		.3, .4, // output B
+	})
+	copy(dense.Biases.Value.Data, []float64{.5, .6})
+
+	output := dense.Forward(tensor.FromSlice([]float64{
+		.7, .8, // sample A
+		.9, .1, // sample B
+	}, 2, 2))
+
+	expected := []float64{
+		(.1 * .7) + (.2 * .8) + .5,
+		(.3 * .7) + (.4 * .8) + .6,
+		(.1 * .9) + (.2 * .1) + .5,
+		(.3 * .9) + (.4 * .1) + .6,
+	}
+	if !slices.Equal(output.Data, expected) {
+		t.Errorf("expected %v but got %v", expected, output.Data)
+	}
+}
+
+func TestDenseBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(9, 10))
+	dense := CreateDense(func(int) float64 { return rng.NormFloat64() }, 5, 3)
+	for i := range dense.Biases.Value.Data {
+		dense.Biases.Value.Data[i] = rng.NormFloat64()
+	}
+
+	checkLayerGradients(t, dense, randomTensor(rng, 3, 5), rng)
+}
+
+func TestDenseInitializeGivesFanInAndFanOut(t *testing.T) {
+	dense := CreateDense(func(int) float64 { return 0 }, 3, 2)
+
+	dense.Initialize(
+		func(values []float64, fanIn, fanOut int) { common.Constant(float64(fanIn))(values, fanIn, fanOut) },
+		func(values []float64, fanIn, fanOut int) { common.Constant(float64(fanOut))(values, fanIn, fanOut) },
+	)
+
+	if !slices.Equal(dense.Weights.Value.Data, []float64{3, 3, 3, 3, 3, 3}) {
+		t.Errorf("expected weights to be initialized with fan in 3 but got %v", dense.Weights.Value.Data)
+	}
+	if !slices.Equal(dense.Biases.Value.Data, []float64{2, 2}) {
+		t.Errorf("expected biases to be initialized with fan out 2 but got %v", dense.Biases.Value.Data)
+	}
+}
+
+func TestCreateANNWithInitializersIsReproducible(t *testing.T) {
+	create := func() ANN {
+		rng := rand.New(rand.NewPCG(27, 28))
+		return CreateANN(nil, 16, 2, WithInitializers(common.XavierUniform(rng), common.Constant(.1)))
+	}
+
+	first, second := create(), create()
+	for p, parameter := range first.Parameters() {
+		if !slices.Equal(parameter.Value.Data, second.Parameters()[p].Value.Data) {
+			t.Errorf("parameter %d: expected the same seed to give the same values", p)
+		}
+	}
+	if first.Layers[0].(*Dense).Biases.Value.Data[0] != .1 {
+		t.Errorf("expected biases to be initialized to 0.1")
+	}
+}
+
+func TestReLULayerBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	checkLayerGradients(t, CreateReLU(), randomTensor(rng, 2, 8), rng)
+}
+
+func TestSoftmaxLayerBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(13, 14))
+	checkLayerGradients(t, CreateSoftmax(), randomTensor(rng, 2, 6), rng)
+}
+
+func TestReLULayerZeroesNegativeInputs(t *testing.T) {
+	relu := CreateReLU()
+
+	output := relu.Forward(tensor.FromSlice([]float64{-1, 0, 2}, 1, 3))
+	if !slices.Equal(output.Data, []float64{0, 0, 2}) {
+		t.Errorf("expected [0 0 2] but got %v", output.Data)
+	}
+
+	inputGradient := relu.Backward(tensor.FromSlice([]float64{.1, .2, .3}, 1, 3))
+	if !slices.Equal(inputGradient.Data, []float64{0, 0, .3}) {
+		t.Errorf("expected [0 0 0.3] but got %v", inputGradient.Data)
+	}
+}
+
+func TestUpdateParametersAppliesAndResetsGradients(t *testing.T) {
+	parameter := &Parameter{
+		Value:    tensor.FromSlice([]float64{1, 2}, 2),
+		Gradient: tensor.FromSlice([]float64{2, 4}, 2),
+	}
+
+	UpdateParameters([]*Parameter{parameter}, .5)
+
+	if !slices.Equal(parameter.Value.Data, []float64{0, 0}) {
+		t.Errorf("expected values [0 0] but got %v", parameter.Value.Data)
+	}
+	if !slices.Equal(parameter.Gradient.Data, []float64{0, 0}) {
+		t.Errorf("expected gradients to be reset but got %v", parameter.Gradient.Data)
+	}
+}
+
+// checks Backward against finite differences of a random weighted sum of the layer output
+func checkLayerGradients(t *testing.T, layer Layer, input *tensor.Tensor, rng *rand.Rand) {
+	t.Helper()
+
+	outputWeights := randomTensor(rng, layer.Forward(input).Shape...)
+	if check := CheckLayerGradients(layer, input, outputWeights); check.MaxRelativeError > 1e-6 {
+		t.Errorf("%T: %s", layer, check)
+	}
+}
+
+func randomTensor(rng *rand.Rand, shape ...int) *tensor.Tensor {
+	t := tensor.New(shape...)
+	for i := range t.Data {
+		t.Data[i] = rng.NormFloat64()
+	}
+	return t
+}
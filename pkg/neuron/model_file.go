@@ -0,0 +1,569 @@
+package neuron
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+	"os"
+	"path"
+)
+
+const (
+	modelMagic   = "OCRN"
+	ModelVersion = 1
+)
+
+// modelRecord is the architecture, parameters and class labels of an ANN.
+// the binary and JSON formats both store this structure
+type modelRecord struct {
+	Version int           `json:"version"`
+	Labels  []string      `json:"labels"`
+	Layers  []layerRecord `json:"layers"`
+}
+
+type layerRecord struct {
+	Type    string        `json:"type"`
+	Config  []int         `json:"config,omitempty"`  // sizes the layer was created with
+	Options []float64     `json:"options,omitempty"` // non integer settings
+	Name    string        `json:"name,omitempty"`
+	Tensors [][]float64   `json:"tensors,omitempty"` // parameter values in Parameters order, then buffers
+	Layers  []layerRecord `json:"layers,omitempty"`  // for nested sequential models
+}
+
+// SaveFile writes the model as JSON when fileName ends in .json and in the binary format otherwise
+func SaveFile(fileName string, ann *ANN) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if path.Ext(fileName) == ".json" {
+		err = SaveJSON(file, ann)
+	} else {
+		err = Save(file, ann)
+	}
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// LoadFile reads a model written by SaveFile
+func LoadFile(fileName string) (*ANN, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if path.Ext(fileName) == ".json" {
+		return LoadJSON(file)
+	}
+	return Load(file)
+}
+
+func SaveJSON(writer io.Writer, ann *ANN) error {
+	record, err := encodeModel(ann)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(record)
+}
+
+func LoadJSON(reader io.Reader) (*ANN, error) {
+	record := modelRecord{}
+	if err := json.NewDecoder(reader).Decode(&record); err != nil {
+		return nil, err
+	}
+
+	return decodeModel(record)
+}
+
+// Save writes the model in the binary format: the magic bytes, a version and
+// then every label and layer, all little endian
+func Save(writer io.Writer, ann *ANN) error {
+	record, err := encodeModel(ann)
+	if err != nil {
+		return err
+	}
+
+	buffered := bufio.NewWriter(writer)
+	out := binaryWriter{writer: buffered}
+
+	out.bytes([]byte(modelMagic))
+	out.uint(uint64(record.Version))
+	out.uint(uint64(len(record.Labels)))
+	for _, label := range record.Labels {
+		out.string(label)
+	}
+	out.layers(record.Layers)
+
+	if out.err != nil {
+		return out.err
+	}
+	return buffered.Flush()
+}
+
+func Load(reader io.Reader) (*ANN, error) {
+	in := binaryReader{reader: bufio.NewReader(reader)}
+
+	if magic := in.bytes(len(modelMagic)); in.err == nil && string(magic) != modelMagic {
+		return nil, errors.New("not a model file")
+	}
+
+	record := modelRecord{Version: int(in.uint())}
+	if in.err == nil && record.Version != ModelVersion {
+		return nil, fmt.Errorf("unsupported model version %d", record.Version)
+	}
+
+	labelCount := in.count()
+	for range labelCount {
+		record.Labels = append(record.Labels, in.string())
+	}
+	record.Layers = in.layers()
+
+	if in.err != nil {
+		return nil, in.err
+	}
+	return decodeModel(record)
+}
+
+func encodeModel(ann *ANN) (modelRecord, error) {
+	layers, err := encodeLayers(ann.Layers)
+	if err != nil {
+		return modelRecord{}, err
+	}
+
+	return modelRecord{
+		Version: ModelVersion,
+		Labels:  ann.Labels,
+		Layers:  layers,
+	}, nil
+}
+
+func decodeModel(record modelRecord) (*ANN, error) {
+	if record.Version != ModelVersion {
+		return nil, fmt.Errorf("unsupported model version %d", record.Version)
+	}
+
+	layers, err := decodeLayers(record.Layers)
+	if err != nil {
+		return nil, err
+	}
+	if size, ok := outputSize(layers); ok && len(record.Labels) > 0 && len(record.Labels) != size {
+		return nil, fmt.Errorf("model has %d labels but %d outputs", len(record.Labels), size)
+	}
+
+	ann := &ANN{
+		Sequential: CreateSequential(layers...),
+		Labels:     record.Labels,
+	}
+	if size := ann.InputSize(); size > 0 { // as CreateANN does, so ForwardPropagation works before any SetInput
+		ann.Input = tensor.New(1, size)
+	}
+	return ann, nil
+}
+
+func encodeLayers(layers []Layer) ([]layerRecord, error) {
+	records := []layerRecord{}
+
+	for _, layer := range layers {
+		record := layerRecord{}
+
+		switch layer := layer.(type) {
+		case *Dense:
+			record = layerRecord{Type: "dense", Config: []int{layer.InputSize, layer.OutputSize}}
+		case *Conv2D:
+			record = layerRecord{Type: "conv2d", Config: []int{
+				layer.InputChannels, layer.InputHeight, layer.InputWidth,
+				layer.OutputChannels, layer.KernelSize, layer.Stride, layer.Padding,
+			}}
+		case *MaxPool2D:
+			record = layerRecord{Type: "maxpool2d", Config: layer.config()}
+		case *AvgPool2D:
+			record = layerRecord{Type: "avgpool2d", Config: layer.config()}
+		case *ActivationLayer:
+			record = layerRecord{Type: "activation", Name: layer.Name, Options: layer.Options}
+		case *PReLU:
+			record = layerRecord{Type: "activation", Name: "prelu"} // the slope is stored as a tensor
+		case *Softmax:
+			record = layerRecord{Type: "softmax"}
+		case *Dropout:
+			record = layerRecord{Type: "dropout", Options: []float64{layer.Rate}}
+		case *BatchNorm:
+			record = layerRecord{Type: "batchnorm", Config: []int{layer.Size}, Options: []float64{layer.Momentum, layer.Epsilon}}
+		case *LayerNorm:
+			record = layerRecord{Type: "layernorm", Config: []int{layer.Size}, Options: []float64{layer.Epsilon}}
+		case *Sequential:
+			nested, err := encodeLayers(layer.Layers)
+			if err != nil {
+				return nil, err
+			}
+			record = layerRecord{Type: "sequential", Layers: nested}
+		default:
+			return nil, fmt.Errorf("cannot save layer of type %T", layer)
+		}
+
+		if _, nested := layer.(*Sequential); !nested {
+			for _, values := range layerTensors(layer) {
+				record.Tensors = append(record.Tensors, values.Data)
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func decodeLayers(records []layerRecord) ([]Layer, error) {
+	layers := []Layer{}
+	noWeights := func(int) float64 { return 0 }
+
+	for _, record := range records {
+		var layer Layer
+
+		switch record.Type {
+		case "dense":
+			if err := expectConfig(record, 2); err != nil {
+				return nil, err
+			}
+			if err := checkDenseConfig(record.Config); err != nil {
+				return nil, err
+			}
+			layer = CreateDense(noWeights, record.Config[0], record.Config[1])
+		case "conv2d":
+			if err := expectConfig(record, 7); err != nil {
+				return nil, err
+			}
+			if err := checkConvolutionConfig(record.Config); err != nil {
+				return nil, err
+			}
+			c := record.Config
+			layer = CreateConv2D(noWeights, c[0], c[1], c[2], c[3], c[4], c[5], c[6])
+		case "maxpool2d":
+			if err := expectConfig(record, 5); err != nil {
+				return nil, err
+			}
+			if err := checkPoolingConfig(record.Config); err != nil {
+				return nil, err
+			}
+			c := record.Config
+			layer = CreateMaxPool2D(c[0], c[1], c[2], c[3], c[4])
+		case "avgpool2d":
+			if err := expectConfig(record, 5); err != nil {
+				return nil, err
+			}
+			if err := checkPoolingConfig(record.Config); err != nil {
+				return nil, err
+			}
+			c := record.Config
+			layer = CreateAvgPool2D(c[0], c[1], c[2], c[3], c[4])
+		case "activation":
+			activation, err := CreateActivation(record.Name, record.Options...)
+			if err != nil {
+				return nil, err
+			}
+			layer = activation
+		case "softmax":
+			layer = CreateSoftmax()
+		case "dropout":
+			if err := expectOptions(record, 1); err != nil {
+				return nil, err
+			}
+			if rate := record.Options[0]; !(rate >= 0 && rate < 1) {
+				return nil, fmt.Errorf("dropout layer has invalid rate %g", rate)
+			}
+			// models load in inference mode, the rng is only used if training continues
+			layer = CreateDropout(record.Options[0], common.NewRand(0, common.DropoutStream))
+		case "batchnorm":
+			if err := expectConfig(record, 1); err != nil {
+				return nil, err
+			}
+			if err := expectOptions(record, 2); err != nil {
+				return nil, err
+			}
+			if err := checkNormalizationConfig(record); err != nil {
+				return nil, err
+			}
+			if momentum := record.Options[0]; !(momentum >= 0 && momentum <= 1) {
+				return nil, fmt.Errorf("batchnorm layer has invalid momentum %g", momentum)
+			}
+			layer = CreateBatchNorm(record.Config[0], record.Options[0], record.Options[1])
+		case "layernorm":
+			if err := expectConfig(record, 1); err != nil {
+				return nil, err
+			}
+			if err := expectOptions(record, 1); err != nil {
+				return nil, err
+			}
+			if err := checkNormalizationConfig(record); err != nil {
+				return nil, err
+			}
+			layer = CreateLayerNorm(record.Config[0], record.Options[0])
+		case "sequential":
+			nested, err := decodeLayers(record.Layers)
+			if err != nil {
+				return nil, err
+			}
+			layer = CreateSequential(nested...)
+		default:
+			return nil, fmt.Errorf("unknown layer type %q", record.Type)
+		}
+
+		if _, nested := layer.(*Sequential); !nested {
+			tensors := layerTensors(layer)
+			if len(tensors) != len(record.Tensors) {
+				return nil, fmt.Errorf("%s layer expects %d tensors but has %d", record.Type, len(tensors), len(record.Tensors))
+			}
+			for i, values := range tensors {
+				if len(record.Tensors[i]) != values.Len() {
+					return nil, fmt.Errorf("%s layer tensor %d expects %d values but has %d", record.Type, i, values.Len(), len(record.Tensors[i]))
+				}
+				copy(values.Data, record.Tensors[i])
+			}
+		}
+
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+func expectConfig(record layerRecord, size int) error {
+	if len(record.Config) != size {
+		return fmt.Errorf("%s layer expects %d config values but has %d", record.Type, size, len(record.Config))
+	}
+	for _, value := range record.Config {
+		if value < 0 {
+			return fmt.Errorf("%s layer has negative config value %d", record.Type, value)
+		}
+	}
+	return nil
+}
+
+// the checks below reject what the layer constructors would terminate on,
+// so a corrupt file gives an error instead
+
+func checkDenseConfig(c []int) error {
+	inputSize, outputSize := c[0], c[1]
+	if inputSize == 0 || outputSize == 0 || inputSize > maxModelCount/outputSize {
+		return fmt.Errorf("dense layer has invalid size %dx%d", inputSize, outputSize)
+	}
+	return nil
+}
+
+func checkConvolutionConfig(c []int) error {
+	channels, height, width, outputChannels, kernelSize, stride, padding := c[0], c[1], c[2], c[3], c[4], c[5], c[6]
+	for _, value := range []int{channels, height, width, outputChannels, kernelSize, stride} {
+		if value == 0 || value > maxModelCount {
+			return fmt.Errorf("conv2d layer has invalid config %v", c)
+		}
+	}
+	if padding > maxModelCount || kernelSize > height+2*padding || kernelSize > width+2*padding {
+		return fmt.Errorf("conv2d kernel %d does not fit input %dx%d with padding %d", kernelSize, height, width, padding)
+	}
+	if channels > maxModelCount/(kernelSize*kernelSize) || outputChannels > maxModelCount/(channels*kernelSize*kernelSize) {
+		return fmt.Errorf("conv2d layer has too many weights: %v", c)
+	}
+	return nil
+}
+
+func checkPoolingConfig(c []int) error {
+	channels, height, width, size, stride := c[0], c[1], c[2], c[3], c[4]
+	if channels == 0 || size == 0 || stride == 0 || size > height || size > width {
+		return fmt.Errorf("pooling window %d with stride %d does not fit input %dx%d", size, stride, height, width)
+	}
+	return nil
+}
+
+func checkNormalizationConfig(record layerRecord) error {
+	size, epsilon := record.Config[0], record.Options[len(record.Options)-1]
+	if size == 0 || size > maxModelCount || !(epsilon > 0) {
+		return fmt.Errorf("%s layer has invalid size %d or epsilon %g", record.Type, size, epsilon)
+	}
+	return nil
+}
+
+// outputSize is the number of outputs of the last layer that knows it, false
+// when no layer does
+func outputSize(layers []Layer) (int, bool) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		switch layer := layers[i].(type) {
+		case *Dense:
+			return layer.OutputSize, true
+		case *Conv2D:
+			return layer.OutputSize(), true
+		case *MaxPool2D:
+			return layer.OutputSize(), true
+		case *AvgPool2D:
+			return layer.OutputSize(), true
+		case *Sequential:
+			if size, ok := outputSize(layer.Layers); ok {
+				return size, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func expectOptions(record layerRecord, size int) error {
+	if len(record.Options) != size {
+		return fmt.Errorf("%s layer expects %d options but has %d", record.Type, size, len(record.Options))
+	}
+	return nil
+}
+
+// layerTensors are the tensors saved for a layer: its parameter values and then its buffers
+func layerTensors(layer Layer) []*tensor.Tensor {
+	tensors := []*tensor.Tensor{}
+	for _, parameter := range layer.Parameters() {
+		tensors = append(tensors, parameter.Value)
+	}
+	if buffered, ok := layer.(BufferedLayer); ok {
+		tensors = append(tensors, buffered.Buffers()...)
+	}
+	return tensors
+}
+
+func (window poolingWindow) config() []int {
+	return []int{window.Channels, window.InputHeight, window.InputWidth, window.Size, window.Stride}
+}
+
+// binaryWriter keeps the first error so every field can be written without checking
+type binaryWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (out *binaryWriter) bytes(value []byte) {
+	if out.err == nil {
+		_, out.err = out.writer.Write(value)
+	}
+}
+
+func (out *binaryWriter) uint(value uint64) {
+	out.bytes(binary.LittleEndian.AppendUint64(nil, value))
+}
+
+func (out *binaryWriter) string(value string) {
+	out.uint(uint64(len(value)))
+	out.bytes([]byte(value))
+}
+
+func (out *binaryWriter) floats(values []float64) {
+	out.uint(uint64(len(values)))
+	if out.err == nil {
+		out.err = binary.Write(out.writer, binary.LittleEndian, values)
+	}
+}
+
+func (out *binaryWriter) layers(records []layerRecord) {
+	out.uint(uint64(len(records)))
+	for _, record := range records {
+		out.string(record.Type)
+		out.string(record.Name)
+		out.uint(uint64(len(record.Config)))
+		for _, value := range record.Config {
+			out.uint(uint64(value))
+		}
+		out.floats(record.Options)
+		out.uint(uint64(len(record.Tensors)))
+		for _, values := range record.Tensors {
+			out.floats(values)
+		}
+		out.layers(record.Layers)
+	}
+}
+
+// binaryReader keeps the first error and returns zero values after it
+type binaryReader struct {
+	reader io.Reader
+	err    error
+}
+
+// upper bound for any count in a model file, so a corrupt file cannot ask for huge allocations
+const maxModelCount = 1 << 28
+
+func (in *binaryReader) bytes(size int) []byte {
+	if in.err != nil {
+		return nil
+	}
+
+	value := make([]byte, size)
+	_, in.err = io.ReadFull(in.reader, value)
+	return value
+}
+
+func (in *binaryReader) uint() uint64 {
+	value := in.bytes(8)
+	if in.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(value)
+}
+
+func (in *binaryReader) count() int {
+	value := in.uint()
+	if in.err == nil && value > maxModelCount {
+		in.err = fmt.Errorf("count %d is too large", value)
+	}
+	if in.err != nil {
+		return 0
+	}
+	return int(value)
+}
+
+func (in *binaryReader) string() string {
+	return string(in.bytes(in.count()))
+}
+
+func (in *binaryReader) floats() []float64 {
+	values := make([]float64, in.count())
+	if in.err == nil && len(values) > 0 {
+		in.err = binary.Read(in.reader, binary.LittleEndian, values)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+func (in *binaryReader) layers() []layerRecord {
+	records := []layerRecord{}
+
+	layerCount := in.count()
+	for range layerCount {
+		record := layerRecord{
+			Type: in.string(),
+			Name: in.string(),
+		}
+		configCount := in.count()
+		for range configCount {
+			record.Config = append(record.Config, int(in.count()))
+		}
+		record.Options = in.floats()
+		tensorCount := in.count()
+		for range tensorCount {
+			record.Tensors = append(record.Tensors, in.floats())
+		}
+		record.Layers = in.layers()
+
+		if in.err != nil {
+			return nil
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
@@ -0,0 +1,187 @@
+package neuron
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func createTestModel() *ANN {
+	rng := rand.New(rand.NewPCG(19, 20))
+	randomFunc := func(fanInSize int) float64 {
+		return rng.NormFloat64() / float64(fanInSize)
+	}
+
+	model := CreateSequential(
+		CreateConv2D(randomFunc, 1, 6, 6, 2, 3, 1, 1),
+		CreateReLU(),
+		CreateMaxPool2D(2, 6, 6, 2, 2),
+		CreateSequential(
+			CreateAvgPool2D(2, 3, 3, 1, 1),
+			CreateIdentity(),
+		),
+		CreateDense(randomFunc, 2*3*3, 10),
+		CreateSoftmax(),
+	)
+	for _, parameter := range model.Parameters() {
+		for i := range parameter.Value.Data {
+			parameter.Value.Data[i] += rng.Float64()
+		}
+	}
+
+	return &ANN{
+		Sequential: model,
+		Labels:     []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"},
+	}
+}
+
+func TestSaveAndLoadProduceIdenticalForwardPropagation(t *testing.T) {
+	formats := map[string]struct {
+		save func(*bytes.Buffer, *ANN) error
+		load func(*bytes.Buffer) (*ANN, error)
+	}{
+		"binary": {
+			save: func(buffer *bytes.Buffer, ann *ANN) error { return Save(buffer, ann) },
+			load: func(buffer *bytes.Buffer) (*ANN, error) { return Load(buffer) },
+		},
+		"json": {
+			save: func(buffer *bytes.Buffer, ann *ANN) error { return SaveJSON(buffer, ann) },
+			load: func(buffer *bytes.Buffer) (*ANN, error) { return LoadJSON(buffer) },
+		},
+	}
+
+	for name, format := range formats {
+		ann := createTestModel()
+		input := make([]float64, 6*6)
+		for i := range input {
+			input[i] = float64(i % 3)
+		}
+		ann.SetInput(input)
+		expectedLogits := slices.Clone(ann.ForwardPropagation())
+
+		buffer := &bytes.Buffer{}
+		if err := format.save(buffer, ann); err != nil {
+			t.Fatalf("%s: could not save: %s", name, err)
+		}
+		loaded, err := format.load(buffer)
+		if err != nil {
+			t.Fatalf("%s: could not load: %s", name, err)
+		}
+
+		loaded.SetInput(input)
+		logits := loaded.ForwardPropagation()
+
+		if !slices.Equal(logits, expectedLogits) {
+			t.Errorf("%s: expected logits %v but got %v", name, expectedLogits, logits)
+		}
+		if !slices.Equal(loaded.OutputVector(), ann.OutputVector()) {
+			t.Errorf("%s: expected output %v but got %v", name, ann.OutputVector(), loaded.OutputVector())
+		}
+		if !slices.Equal(loaded.Labels, ann.Labels) {
+			t.Errorf("%s: expected labels %v but got %v", name, ann.Labels, loaded.Labels)
+		}
+	}
+}
+
+func TestSaveFileChoosesFormatByExtension(t *testing.T) {
+	ann := CreateANN(func(int) float64 { return .1 }, 4, 1)
+	dir := t.TempDir()
+
+	for _, fileName := range []string{"model.bin", "model.json"} {
+		filePath := path.Join(dir, fileName)
+		if err := SaveFile(filePath, &ann); err != nil {
+			t.Fatalf("could not save %s: %s", fileName, err)
+		}
+
+		loaded, err := LoadFile(filePath)
+		if err != nil {
+			t.Fatalf("could not load %s: %s", fileName, err)
+		}
+		if !slices.Equal(loaded.LayerSizes(), ann.LayerSizes()) {
+			t.Errorf("%s: expected layer sizes %v but got %v", fileName, ann.LayerSizes(), loaded.LayerSizes())
+		}
+	}
+}
+
+func TestLoadRejectsUnknownFiles(t *testing.T) {
+	if _, err := Load(strings.NewReader("PNG not a model")); err == nil {
+		t.Errorf("expected an error for bad magic bytes")
+	}
+
+	ann := CreateANN(func(int) float64 { return .1 }, 4, 1)
+	buffer := &bytes.Buffer{}
+	if err := Save(buffer, &ann); err != nil {
+		t.Fatalf("could not save: %s", err)
+	}
+
+	unsupportedVersion := buffer.Bytes()
+	unsupportedVersion[len(modelMagic)] = ModelVersion + 1
+	if _, err := Load(bytes.NewReader(unsupportedVersion)); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("expected a version error but got %v", err)
+	}
+
+	truncated := unsupportedVersion[:len(unsupportedVersion)/2]
+	truncated[len(modelMagic)] = ModelVersion
+	if _, err := Load(bytes.NewReader(truncated)); err == nil {
+		t.Errorf("expected an error for a truncated file")
+	}
+
+	if _, err := LoadJSON(strings.NewReader(`{"version": 1, "layers": [{"type": "dense", "config": [2, 2], "tensors": [[1]]}]}`)); err == nil {
+		t.Errorf("expected an error for a tensor of the wrong size")
+	}
+}
+
+func TestLoadRejectsInvalidLayerConfigs(t *testing.T) {
+	for name, layer := range map[string]string{
+		"zero stride":        `{"type": "conv2d", "config": [1, 6, 6, 2, 3, 0, 1]}`,
+		"kernel too large":   `{"type": "conv2d", "config": [1, 6, 6, 2, 9, 1, 1]}`,
+		"zero kernel":        `{"type": "conv2d", "config": [1, 6, 6, 2, 0, 1, 0]}`,
+		"window too large":   `{"type": "maxpool2d", "config": [2, 6, 6, 7, 1]}`,
+		"zero pool stride":   `{"type": "avgpool2d", "config": [2, 6, 6, 2, 0]}`,
+		"huge dense":         `{"type": "dense", "config": [268435456, 268435456]}`,
+		"empty dense":        `{"type": "dense", "config": [0, 4]}`,
+		"zero batch norm":    `{"type": "batchnorm", "config": [0], "options": [0.9, 0.00001]}`,
+		"bad momentum":       `{"type": "batchnorm", "config": [4], "options": [2, 0.00001]}`,
+		"zero epsilon":       `{"type": "layernorm", "config": [4], "options": [0]}`,
+		"dropout rate of 1":  `{"type": "dropout", "options": [1]}`,
+		"negative dropout":   `{"type": "dropout", "options": [-0.5]}`,
+		"negative dimension": `{"type": "dense", "config": [-1, 4]}`,
+	} {
+		if _, err := LoadJSON(strings.NewReader(`{"version": 1, "layers": [` + layer + `]}`)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadRejectsLabelsThatDoNotMatchTheOutputs(t *testing.T) {
+	ann := CreateANN(func(int) float64 { return .1 }, 4, 1, WithLabels([]string{"a", "b", "c"}))
+	ann.Labels = ann.Labels[:2]
+
+	buffer := &bytes.Buffer{}
+	if err := Save(buffer, &ann); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(buffer); err == nil || !strings.Contains(err.Error(), "labels") {
+		t.Errorf("expected a label error but got %v", err)
+	}
+}
+
+func TestLoadedModelsHaveAnInput(t *testing.T) {
+	ann := CreateANN(func(int) float64 { return .1 }, 4, 1)
+	buffer := &bytes.Buffer{}
+	if err := Save(buffer, &ann); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Input == nil || loaded.Input.Len() != 4 {
+		t.Fatalf("expected an input of 4 values but got %v", loaded.Input)
+	}
+	loaded.ForwardPropagation()
+}
@@ -5,31 +5,95 @@ import (
 	"image"
 	"image/color"
 	"math"
+	"math/rand/v2"
 	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
 )
 
-type Weight struct {
-	Value float64
+// ANN is a Sequential model ending in a softmax, run one sample at a time.
+// its weights live in tensors, Graph gives a neuron and edge view of them
+type ANN struct {
+	*Sequential
+
+	Input  *tensor.Tensor // 1 x input size
+	Output *tensor.Tensor // 1 x output size, the softmax of the last ForwardPropagation
+	Labels []string       // class name of every output
 }
 
-type Edge struct {
-	Weight *Weight
-	Neuron *Neuron
+// ANNOption changes how CreateANN builds a network
+type ANNOption func(*annOptions)
+
+type annOptions struct {
+	hiddenActivations []func() Layer
+	weights           common.Initializer
+	biases            common.Initializer
+	dropoutRate       float64
+	dropoutRng        *rand.Rand
+	normalization     func(size int) Layer
+	labels            []string
 }
 
-type Neuron struct {
-	Input      []*Edge
-	Output     []*Edge
-	Bias       float64
-	Activation float64
+// WithHiddenActivations picks the activation of every hidden layer, in order
+// from the input. when there are more hidden layers than activations the
+// last activation is used for the rest
+func WithHiddenActivations(createActivations ...func() Layer) ANNOption {
+	return func(options *annOptions) {
+		options.hiddenActivations = createActivations
+	}
 }
 
-type ANN struct {
-	InputLayer  []*Neuron
-	OutputLayer []*Neuron
+// WithInitializers fills the weights and biases of every dense layer with
+// the given initializers instead of the randomFunc given to CreateANN and zero biases
+func WithInitializers(weights, biases common.Initializer) ANNOption {
+	return func(options *annOptions) {
+		options.weights = weights
+		options.biases = biases
+	}
+}
+
+// WithDropout adds a Dropout layer after every hidden activation
+func WithDropout(rate float64, rng *rand.Rand) ANNOption {
+	return func(options *annOptions) {
+		options.dropoutRate = rate
+		options.dropoutRng = rng
+	}
 }
 
-func CreateANN(randomFunc func(int) float64, inputLayerSize, numberOfHiddenLayers int) ANN {
+// WithNormalization puts a normalization layer, such as a BatchNorm or a
+// LayerNorm, between every hidden dense layer and its activation
+func WithNormalization(create func(size int) Layer) ANNOption {
+	return func(options *annOptions) {
+		options.normalization = create
+	}
+}
+
+// WithLabels gives the network one output per label instead of the digits 0-9
+func WithLabels(labels []string) ANNOption {
+	return func(options *annOptions) {
+		options.labels = labels
+	}
+}
+
+// CreateANN builds hidden layers, each half the size of the previous one and
+// followed by a ReLU unless WithHiddenActivations says otherwise, and then one
+// softmax output per label
+func CreateANN(randomFunc func(int) float64, inputLayerSize, numberOfHiddenLayers int, options ...ANNOption) ANN {
+	chosen := annOptions{
+		hiddenActivations: []func() Layer{func() Layer { return CreateReLU() }},
+		weights:           common.FromRandomFunc(randomFunc),
+		biases:            common.Constant(0),
+		labels:            []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"},
+	}
+	for _, option := range options {
+		option(&chosen)
+	}
+	if len(chosen.hiddenActivations) == 0 {
+		common.PrintAndTerminate("at least one hidden activation is needed")
+	}
+	if len(chosen.labels) == 0 {
+		common.PrintAndTerminate("at least one label is needed")
+	}
+
 	layerSizes := []int{}
 	{ // plot the size of each layer
 		layerSizes = append(layerSizes, inputLayerSize)
@@ -37,131 +101,110 @@ func CreateANN(randomFunc func(int) float64, inputLayerSize, numberOfHiddenLayer
 			reductionDivisior := int(math.Pow(2, float64(i)))
 			layerSizes = append(layerSizes, inputLayerSize/reductionDivisior)
 		}
-		layerSizes = append(layerSizes, 10)
+		layerSizes = append(layerSizes, len(chosen.labels))
 	}
 
-	var firstLayer []*Neuron
-	lastLayer := []*Neuron{}
-
-	for _, layerSize := range layerSizes {
-		var currentLayer []*Neuron
-		for range layerSize {
-			currentLayer = append(currentLayer, &Neuron{
-				Activation: 0,
-				Bias:       0,
-				Input:      nil, // we fill this in below
-				Output:     nil, // we fill this in below
-			})
-		}
-
-		for _, lastNeuron := range lastLayer { // connect the graph bipartite
-			for _, currentNeuron := range currentLayer {
-				weight := Weight{Value: randomFunc(len(lastLayer))}
-				lastNeuron.Output = append(lastNeuron.Output, &Edge{
-					Neuron: currentNeuron,
-					Weight: &weight,
-				})
-				currentNeuron.Input = append(currentNeuron.Input, &Edge{
-					Neuron: lastNeuron,
-					Weight: &weight,
-				})
+	layers := []Layer{}
+	for i := 1; i < len(layerSizes); i++ {
+		dense := CreateDense(func(int) float64 { return 0 }, layerSizes[i-1], layerSizes[i])
+		dense.Initialize(chosen.weights, chosen.biases)
+		layers = append(layers, dense)
+		if i < len(layerSizes)-1 {
+			if chosen.normalization != nil {
+				layers = append(layers, chosen.normalization(layerSizes[i]))
+			}
+			createActivation := chosen.hiddenActivations[min(i, len(chosen.hiddenActivations))-1]
+			layers = append(layers, createActivation())
+			if chosen.dropoutRate > 0 {
+				layers = append(layers, CreateDropout(chosen.dropoutRate, chosen.dropoutRng))
 			}
-		}
-		lastLayer = currentLayer
-		if firstLayer == nil {
-			firstLayer = currentLayer
 		}
 	}
+	layers = append(layers, CreateSoftmax())
 
-	ann := ANN{
-		InputLayer:  firstLayer,
-		OutputLayer: lastLayer,
+	return ANN{
+		Sequential: CreateSequential(layers...),
+		Input:      tensor.New(1, inputLayerSize),
+		Labels:     chosen.labels,
 	}
-
-	return ann
 }
 
+// ForwardPropagation runs Input through the network and returns the logits
+// that were given to the softmax
 func (ann *ANN) ForwardPropagation() []float64 {
-	calculateHiddenLayerActivations := func(inputLayer []*Neuron) {
-		currentLayer := inputLayer
-
-		for len(currentLayer) > 0 {
-			activations := map[*Neuron]float64{}
-
-			for _, inputNeuron := range currentLayer {
-				for _, inputNeuronEdge := range inputNeuron.Output {
-					outputNeuron := inputNeuronEdge.Neuron
-					activations[outputNeuron] += (inputNeuronEdge.Weight.Value * inputNeuron.Activation)
-				}
-			}
+	ann.Output = ann.Forward(ann.Input)
 
-			nextLayer := []*Neuron{}
-			for outputNeuron, activation := range activations {
-				outputNeuron.Activation = common.ReLU(activation + outputNeuron.Bias)
-				nextLayer = append(nextLayer, outputNeuron)
-			}
-
-			currentLayer = nextLayer
-		}
+	softmax, ok := ann.Layers[len(ann.Layers)-1].(*Softmax)
+	if !ok {
+		common.PrintAndTerminate("the last layer of an ANN must be a softmax")
 	}
 
-	calculateOutputLayerActivations := func(outputLayer []*Neuron) []float64 {
-		logits := make([]float64, len(outputLayer))
-
-		for i, neuron := range outputLayer {
-			logit := float64(0)
-			for _, inputNeuron := range neuron.Input {
-				activation := inputNeuron.Neuron.Activation
-				weight := inputNeuron.Weight.Value
+	return softmax.input.Row(0)
+}
 
-				logit += float64(activation * weight)
-			}
+// BackwardPropagation updates every weight and bias and returns the gradient of
+// the loss w.r.t the input layer, so that layers in front of the network can learn
+func (ann *ANN) BackwardPropagation(expectedOneHotEncoding []float64, learningRate float64) []float64 {
+	expected := tensor.FromSlice(expectedOneHotEncoding, 1, len(expectedOneHotEncoding))
+	return ann.Sequential.BackwardPropagation(expected, learningRate).Row(0)
+}
 
-			bias := neuron.Bias
-			logits[i] = logit + float64(bias)
-		}
+// OutputVector is the softmax of the last ForwardPropagation
+func (ann *ANN) OutputVector() []float64 {
+	return ann.Output.Row(0)
+}
 
-		for i, value := range common.SoftMax(logits) {
-			outputLayer[i].Activation = value
+// LayerSizes is the number of neurons in every layer of a dense network, input first
+func (ann *ANN) LayerSizes() []int {
+	sizes := []int{}
+	for _, layer := range ann.Layers {
+		if dense, ok := layer.(*Dense); ok {
+			if len(sizes) == 0 {
+				sizes = append(sizes, dense.InputSize)
+			}
+			sizes = append(sizes, dense.OutputSize)
 		}
-
-		return logits
 	}
-
-	calculateHiddenLayerActivations(ann.InputLayer)
-	logits := calculateOutputLayerActivations(ann.OutputLayer)
-
-	return logits
+	return sizes
 }
 
-func (ann *ANN) BackwardPropagation(expectedOneHotEncoding []float64, learningRate float64) {
-	softmaxVector := outputToVector(ann.OutputLayer)
-
-	// find softmax cross entropy gradient of loss w.r.t softmax
-	gradientVector := common.SoftmaxCrossEntropyGradient(softmaxVector, expectedOneHotEncoding)
-
-	// update output layer weights
-	for j, outputNode := range ann.OutputLayer {
-		for _, inputEdge := range outputNode.Input {
-			crossEntroypLossGradient := gradientVector[j]
-			gradientOfLossWithRespectToWeight := crossEntroypLossGradient * inputEdge.Neuron.Activation
-			delta := learningRate * gradientOfLossWithRespectToWeight
+// InputSize is the number of inputs of the first layer that knows it, 0 when
+// no layer does
+func (ann *ANN) InputSize() int {
+	return inputSize(ann.Layers)
+}
 
-			inputEdge.Weight.Value -= delta
-		}
+// ImageSize is the width and height of the square images the model takes,
+// checked against size unless that is 0
+func (ann *ANN) ImageSize(size int) (int, error) {
+	inputs := ann.InputSize()
+	if size == 0 {
+		size = int(math.Round(math.Sqrt(float64(inputs))))
 	}
-
-	// RELU gradiant for every hidden layer
+	if size*size != inputs {
+		return 0, fmt.Errorf("model takes %d inputs, which images of %dx%d do not give", inputs, size, size)
+	}
+	return size, nil
 }
 
-func outputToVector(neuron []*Neuron) []float64 {
-	vector := make([]float64, len(neuron))
-
-	for i := range len(neuron) {
-		vector[i] = neuron[i].Activation
+func inputSize(layers []Layer) int {
+	for _, layer := range layers {
+		switch layer := layer.(type) {
+		case *Dense:
+			return layer.InputSize
+		case *Conv2D:
+			return layer.InputChannels * layer.InputHeight * layer.InputWidth
+		case *MaxPool2D:
+			return layer.inputSize()
+		case *AvgPool2D:
+			return layer.inputSize()
+		case *Sequential:
+			if size := inputSize(layer.Layers); size > 0 {
+				return size
+			}
+		}
 	}
-
-	return vector
+	return 0
 }
 
 func colorsEqual(c1, c2 color.Color) bool {
@@ -170,41 +213,40 @@ func colorsEqual(c1, c2 color.Color) bool {
 	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
 }
 
-func (ann *ANN) InputEncoding(img image.Image) {
+// EncodeImage turns black pixels into 0 and every other pixel into 1
+func EncodeImage(img image.Image) []float64 {
 	bounds := img.Bounds()
+	encoding := make([]float64, bounds.Max.X*bounds.Max.Y)
 
 	for x := bounds.Min.X; x < bounds.Max.X; x++ {
 		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 			neuronIdx := (x * bounds.Max.X) + y
-			neuron := ann.InputLayer[neuronIdx]
 
 			if colorsEqual(color.Black, img.At(x, y)) {
-				neuron.Activation = 0
+				encoding[neuronIdx] = 0
 			} else {
-				neuron.Activation = 1
+				encoding[neuronIdx] = 1
 			}
 		}
 	}
-}
 
-func (ann *ANN) Print(bindFunc func(string)) {
-	currentLayer := ann.InputLayer
-
-	for len(currentLayer) > 0 {
-		nextLayer := map[*Neuron]bool{}
-		currentLayerString := ""
-		for _, node := range currentLayer {
-			currentLayerString += fmt.Sprintf("Neuron(%f) | ", node.Activation)
-			for _, nextNode := range node.Output {
-				nextLayer[nextNode.Neuron] = true
-			}
-		}
+	return encoding
+}
 
-		bindFunc(currentLayerString)
+func (ann *ANN) InputEncoding(img image.Image) {
+	ann.SetInput(EncodeImage(img))
+}
 
-		currentLayer = []*Neuron{}
-		for node := range nextLayer {
-			currentLayer = append(currentLayer, node)
-		}
+// SetInput copies a feature vector, eg: the output of a Conv2D, into the input layer
+func (ann *ANN) SetInput(input []float64) {
+	if ann.Input == nil || ann.Input.Len() != len(input) {
+		ann.Input = tensor.New(1, len(input))
 	}
+
+	copy(ann.Input.Data, input)
+}
+
+func (ann *ANN) Print(bindFunc func(string)) {
+	graph := ann.Graph()
+	graph.Print(bindFunc)
 }
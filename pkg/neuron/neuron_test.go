@@ -4,7 +4,9 @@ import (
 	"image"
 	"image/color"
 	"maps"
+	"math/rand/v2"
 	"ocr_cnn/pkg/common"
+	"slices"
 	"testing"
 )
 
@@ -13,7 +15,8 @@ func TestGraphIsConnectedWithRandomWeightsAndBias(t *testing.T) {
 	randomFunc := func(fanInSize int) float64 {
 		return randomNumber
 	}
-	ann := CreateANN(randomFunc, 2, 1)
+	network := CreateANN(randomFunc, 2, 1)
+	ann := network.Graph()
 	expectedLayerSizes := []int{2, 1, 10}
 
 	currentLayer := ann.InputLayer
@@ -203,7 +206,7 @@ func TestForwardPropagationPeformsCorrectCalculations(t *testing.T) {
 		&outputNeuronB: common.SoftMax(logits)[1],
 	}
 
-	ann := &ANN{
+	ann := &Graph{
 		InputLayer: []*Neuron{
 			&inputNeuronA, &inputNeuronB,
 		},
@@ -310,7 +313,7 @@ func TestBackwardPropagationPerformsCorrectCalculationsOnOutputLayer(t *testing.
 		}
 	}
 
-	ann := &ANN{
+	ann := &Graph{
 		InputLayer: []*Neuron{
 			&inputNeuronA, &inputNeuronB,
 		},
@@ -352,7 +355,7 @@ func TestInputEncoding(t *testing.T) {
 	neuronC := &Neuron{}
 	neuronD := &Neuron{}
 
-	ann := &ANN{
+	ann := &Graph{
 		InputLayer: []*Neuron{
 			neuronA,
 			neuronB,
@@ -384,3 +387,196 @@ func TestInputEncoding(t *testing.T) {
 		}
 	}
 }
+
+func TestBackwardPropagationMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randomFunc := func(fanInSize int) float64 {
+		return rng.NormFloat64() / float64(fanInSize)
+	}
+	network := CreateANN(randomFunc, 8, 2)
+	ann := network.Graph()
+
+	neurons := allNeurons(&ann)
+	for _, neuron := range neurons {
+		neuron.Bias = rng.Float64() / 10
+	}
+
+	input := []float64{1, 0, 1, 1, 0, 1, 0, 1}
+	for i, neuron := range ann.InputLayer {
+		neuron.Activation = input[i]
+	}
+
+	expectedOneHotEncoding := make([]float64, 10)
+	expectedOneHotEncoding[3] = 1
+
+	loss := func() float64 {
+		ann.ForwardPropagation()
+		return common.CrossEntropyLoss(expectedOneHotEncoding, outputToVector(ann.OutputLayer))
+	}
+
+	const epsilon = 1e-6
+	numericalWeightGradients := map[*Weight]float64{}
+	numericalBiasGradients := map[*Neuron]float64{}
+	for _, neuron := range neurons {
+		for _, inputEdge := range neuron.Input {
+			original := inputEdge.Weight.Value
+			inputEdge.Weight.Value = original + epsilon
+			lossPlus := loss()
+			inputEdge.Weight.Value = original - epsilon
+			lossMinus := loss()
+			inputEdge.Weight.Value = original
+
+			numericalWeightGradients[inputEdge.Weight] = (lossPlus - lossMinus) / (2 * epsilon)
+		}
+
+		if len(neuron.Input) > 0 {
+			original := neuron.Bias
+			neuron.Bias = original + epsilon
+			lossPlus := loss()
+			neuron.Bias = original - epsilon
+			lossMinus := loss()
+			neuron.Bias = original
+
+			numericalBiasGradients[neuron] = (lossPlus - lossMinus) / (2 * epsilon)
+		}
+	}
+
+	// with a learning rate of 1 the change in every parameter is exactly its gradient
+	weightsBefore := map[*Weight]float64{}
+	for weight := range numericalWeightGradients {
+		weightsBefore[weight] = weight.Value
+	}
+	biasesBefore := map[*Neuron]float64{}
+	for neuron := range numericalBiasGradients {
+		biasesBefore[neuron] = neuron.Bias
+	}
+
+	numericalInputGradients := make([]float64, len(ann.InputLayer))
+	for i, neuron := range ann.InputLayer {
+		original := neuron.Activation
+		neuron.Activation = original + epsilon
+		lossPlus := loss()
+		neuron.Activation = original - epsilon
+		lossMinus := loss()
+		neuron.Activation = original
+
+		numericalInputGradients[i] = (lossPlus - lossMinus) / (2 * epsilon)
+	}
+
+	loss()
+	inputGradients := ann.BackwardPropagation(expectedOneHotEncoding, 1)
+
+	for i, numericalGradient := range numericalInputGradients {
+		if relativeError(inputGradients[i], numericalGradient) > 1e-5 {
+			t.Errorf("input gradient %d: expected %e but got %e", i, numericalGradient, inputGradients[i])
+		}
+	}
+
+	for weight, numericalGradient := range numericalWeightGradients {
+		analyticalGradient := weightsBefore[weight] - weight.Value
+		if relativeError(analyticalGradient, numericalGradient) > 1e-5 {
+			t.Errorf("weight gradient: expected %e but got %e", numericalGradient, analyticalGradient)
+		}
+	}
+
+	for neuron, numericalGradient := range numericalBiasGradients {
+		analyticalGradient := biasesBefore[neuron] - neuron.Bias
+		if relativeError(analyticalGradient, numericalGradient) > 1e-5 {
+			t.Errorf("bias gradient: expected %e but got %e", numericalGradient, analyticalGradient)
+		}
+	}
+}
+
+func TestBackwardPropagationUpdatesHiddenLayerWeightsAndBiases(t *testing.T) {
+	count := 0
+	randomFunc := func(fanInSize int) float64 {
+		count++
+		return float64(count%7) / 10
+	}
+	network := CreateANN(randomFunc, 4, 1)
+	ann := network.Graph()
+	for i, neuron := range ann.InputLayer {
+		neuron.Activation = float64(i % 2)
+	}
+
+	hiddenNeuron := ann.InputLayer[0].Output[0].Neuron
+	weightBefore := ann.InputLayer[1].Output[0].Weight.Value
+	biasBefore := hiddenNeuron.Bias
+
+	expectedOneHotEncoding := make([]float64, 10)
+	expectedOneHotEncoding[0] = 1
+
+	ann.ForwardPropagation()
+	ann.BackwardPropagation(expectedOneHotEncoding, .1)
+
+	if ann.InputLayer[1].Output[0].Weight.Value == weightBefore {
+		t.Errorf("expected hidden layer weight to change from %f", weightBefore)
+	}
+	if hiddenNeuron.Bias == biasBefore {
+		t.Errorf("expected hidden layer bias to change from %f", biasBefore)
+	}
+}
+
+func allNeurons(ann *Graph) []*Neuron {
+	neurons := []*Neuron{}
+	seen := map[*Neuron]bool{}
+
+	currentLayer := ann.InputLayer
+	for len(currentLayer) > 0 {
+		nextLayer := []*Neuron{}
+		for _, neuron := range currentLayer {
+			if seen[neuron] {
+				continue
+			}
+			seen[neuron] = true
+			neurons = append(neurons, neuron)
+
+			for _, outputEdge := range neuron.Output {
+				nextLayer = append(nextLayer, outputEdge.Neuron)
+			}
+		}
+		currentLayer = nextLayer
+	}
+
+	return neurons
+}
+
+func TestCreateANNHasAnOutputForEveryLabel(t *testing.T) {
+	labels := []string{"A", "B", "C", "a", "!"}
+	ann := CreateANN(func(int) float64 { return .1 }, 8, 1, WithLabels(labels))
+
+	ann.SetInput(make([]float64, 8))
+	ann.ForwardPropagation()
+
+	if len(ann.OutputVector()) != len(labels) {
+		t.Errorf("expected %d outputs but got %d", len(labels), len(ann.OutputVector()))
+	}
+	if !slices.Equal(ann.Labels, labels) {
+		t.Errorf("expected labels %v but got %v", labels, ann.Labels)
+	}
+}
+
+func TestInputSizeIsTakenFromTheFirstLayerThatKnowsIt(t *testing.T) {
+	dense := CreateANN(func(int) float64 { return 0 }, 16, 1)
+	if size := dense.InputSize(); size != 16 {
+		t.Errorf("expected 16 inputs but got %d", size)
+	}
+
+	if size := createTestModel().InputSize(); size != 36 {
+		t.Errorf("expected the 36 inputs of the convolution but got %d", size)
+	}
+}
+
+func TestImageSizeComesFromTheModelOrIsChecked(t *testing.T) {
+	ann := CreateANN(func(int) float64 { return 0 }, 16, 1)
+
+	if size, err := ann.ImageSize(0); err != nil || size != 4 {
+		t.Errorf("expected 4x4 images but got %d and %v", size, err)
+	}
+	if size, err := ann.ImageSize(4); err != nil || size != 4 {
+		t.Errorf("expected the matching size to be kept but got %d and %v", size, err)
+	}
+	if _, err := ann.ImageSize(5); err == nil {
+		t.Errorf("expected an error for 5x5 images")
+	}
+}
@@ -0,0 +1,255 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+// BatchNorm normalizes every feature over the samples of a batch while
+// training and over running statistics of past batches during inference,
+// then applies a learned Scale and Shift
+type BatchNorm struct {
+	Size     int
+	Momentum float64 // how much of the running statistics is kept every batch
+	Epsilon  float64
+	Scale    *Parameter
+	Shift    *Parameter
+
+	RunningMean     *tensor.Tensor
+	RunningVariance *tensor.Tensor
+
+	training bool
+	cache    normalizationCache
+}
+
+// normalizationCache is what Forward keeps for Backward
+type normalizationCache struct {
+	normalized *tensor.Tensor
+	inverseStd [][]float64 // per row for LayerNorm, one row for BatchNorm
+	batchStats bool        // whether the statistics depended on the input
+}
+
+func CreateBatchNorm(size int, momentum, epsilon float64) *BatchNorm {
+	if size <= 0 || momentum < 0 || momentum > 1 || epsilon <= 0 {
+		common.PrintAndTerminate(fmt.Sprintf("invalid batch norm size: %d momentum: %f epsilon: %f", size, momentum, epsilon))
+	}
+
+	batchNorm := &BatchNorm{
+		Size:            size,
+		Momentum:        momentum,
+		Epsilon:         epsilon,
+		Scale:           createParameter(size),
+		Shift:           createParameter(size),
+		RunningMean:     tensor.New(size),
+		RunningVariance: tensor.New(size),
+	}
+	batchNorm.Scale.Value.Fill(1)
+	batchNorm.RunningVariance.Fill(1)
+
+	return batchNorm
+}
+
+func (batchNorm *BatchNorm) SetTraining(training bool) {
+	batchNorm.training = training
+}
+
+func (batchNorm *BatchNorm) Forward(input *tensor.Tensor) *tensor.Tensor {
+	checkFeatures(input, batchNorm.Size, "batch norm")
+	batchSize := input.Shape[0]
+
+	mean := batchNorm.RunningMean.Data
+	variance := batchNorm.RunningVariance.Data
+	if batchNorm.training {
+		mean = make([]float64, batchNorm.Size)
+		variance = make([]float64, batchNorm.Size)
+		for n := range batchSize {
+			for j, x := range input.Row(n) {
+				mean[j] += x / float64(batchSize)
+			}
+		}
+		for n := range batchSize {
+			for j, x := range input.Row(n) {
+				variance[j] += (x - mean[j]) * (x - mean[j]) / float64(batchSize)
+			}
+		}
+
+		for j := range batchNorm.Size {
+			batchNorm.RunningMean.Data[j] = batchNorm.Momentum*batchNorm.RunningMean.Data[j] + (1-batchNorm.Momentum)*mean[j]
+			batchNorm.RunningVariance.Data[j] = batchNorm.Momentum*batchNorm.RunningVariance.Data[j] + (1-batchNorm.Momentum)*variance[j]
+		}
+	}
+
+	inverseStd := make([]float64, batchNorm.Size)
+	for j := range inverseStd {
+		inverseStd[j] = 1 / math.Sqrt(variance[j]+batchNorm.Epsilon)
+	}
+
+	normalized := tensor.New(input.Shape...)
+	for n := range batchSize {
+		normalizedRow := normalized.Row(n)
+		for j, x := range input.Row(n) {
+			normalizedRow[j] = (x - mean[j]) * inverseStd[j]
+		}
+	}
+	batchNorm.cache = normalizationCache{
+		normalized: normalized,
+		inverseStd: [][]float64{inverseStd},
+		batchStats: batchNorm.training,
+	}
+
+	return scaleAndShift(normalized, batchNorm.Scale, batchNorm.Shift)
+}
+
+func (batchNorm *BatchNorm) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	normalizedGradient := accumulateScaleAndShift(outputGradient, batchNorm.cache.normalized, batchNorm.Scale, batchNorm.Shift)
+	batchSize := outputGradient.Shape[0]
+	inverseStd := batchNorm.cache.inverseStd[0]
+	inputGradient := tensor.New(outputGradient.Shape...)
+
+	if !batchNorm.cache.batchStats { // the running statistics are constants
+		for n := range batchSize {
+			inputRow := inputGradient.Row(n)
+			for j, gradient := range normalizedGradient.Row(n) {
+				inputRow[j] = gradient * inverseStd[j]
+			}
+		}
+		return inputGradient
+	}
+
+	sums := make([]float64, batchNorm.Size)
+	dots := make([]float64, batchNorm.Size)
+	for n := range batchSize {
+		normalized := batchNorm.cache.normalized.Row(n)
+		for j, gradient := range normalizedGradient.Row(n) {
+			sums[j] += gradient
+			dots[j] += gradient * normalized[j]
+		}
+	}
+	for n := range batchSize {
+		normalized := batchNorm.cache.normalized.Row(n)
+		inputRow := inputGradient.Row(n)
+		for j, gradient := range normalizedGradient.Row(n) {
+			inputRow[j] = inverseStd[j] * (float64(batchSize)*gradient - sums[j] - normalized[j]*dots[j]) / float64(batchSize)
+		}
+	}
+
+	return inputGradient
+}
+
+func (batchNorm *BatchNorm) Parameters() []*Parameter {
+	return []*Parameter{batchNorm.Scale, batchNorm.Shift}
+}
+
+func (batchNorm *BatchNorm) Buffers() []*tensor.Tensor {
+	return []*tensor.Tensor{batchNorm.RunningMean, batchNorm.RunningVariance}
+}
+
+// LayerNorm normalizes the features of every sample on its own, the same way
+// in training and inference, then applies a learned Scale and Shift
+type LayerNorm struct {
+	Size    int
+	Epsilon float64
+	Scale   *Parameter
+	Shift   *Parameter
+
+	cache normalizationCache
+}
+
+func CreateLayerNorm(size int, epsilon float64) *LayerNorm {
+	if size <= 0 || epsilon <= 0 {
+		common.PrintAndTerminate(fmt.Sprintf("invalid layer norm size: %d epsilon: %f", size, epsilon))
+	}
+
+	layerNorm := &LayerNorm{
+		Size:    size,
+		Epsilon: epsilon,
+		Scale:   createParameter(size),
+		Shift:   createParameter(size),
+	}
+	layerNorm.Scale.Value.Fill(1)
+
+	return layerNorm
+}
+
+func (layerNorm *LayerNorm) Forward(input *tensor.Tensor) *tensor.Tensor {
+	checkFeatures(input, layerNorm.Size, "layer norm")
+
+	normalized := tensor.New(input.Shape...)
+	inverseStds := [][]float64{}
+	for n := range input.Shape[0] {
+		row := input.Row(n)
+
+		mean, variance := float64(0), float64(0)
+		for _, x := range row {
+			mean += x / float64(len(row))
+		}
+		for _, x := range row {
+			variance += (x - mean) * (x - mean) / float64(len(row))
+		}
+
+		inverseStd := 1 / math.Sqrt(variance+layerNorm.Epsilon)
+		normalizedRow := normalized.Row(n)
+		for j, x := range row {
+			normalizedRow[j] = (x - mean) * inverseStd
+		}
+		inverseStds = append(inverseStds, []float64{inverseStd})
+	}
+	layerNorm.cache = normalizationCache{normalized: normalized, inverseStd: inverseStds, batchStats: true}
+
+	return scaleAndShift(normalized, layerNorm.Scale, layerNorm.Shift)
+}
+
+func (layerNorm *LayerNorm) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	normalizedGradient := accumulateScaleAndShift(outputGradient, layerNorm.cache.normalized, layerNorm.Scale, layerNorm.Shift)
+	inputGradient := tensor.New(outputGradient.Shape...)
+	size := float64(layerNorm.Size)
+
+	for n := range outputGradient.Shape[0] {
+		gradients := normalizedGradient.Row(n)
+		normalized := layerNorm.cache.normalized.Row(n)
+		inputRow := inputGradient.Row(n)
+
+		sum, dot := float64(0), float64(0)
+		for j, gradient := range gradients {
+			sum += gradient
+			dot += gradient * normalized[j]
+		}
+		for j, gradient := range gradients {
+			inputRow[j] = layerNorm.cache.inverseStd[n][0] * (size*gradient - sum - normalized[j]*dot) / size
+		}
+	}
+
+	return inputGradient
+}
+
+func (layerNorm *LayerNorm) Parameters() []*Parameter {
+	return []*Parameter{layerNorm.Scale, layerNorm.Shift}
+}
+
+func scaleAndShift(normalized *tensor.Tensor, scale, shift *Parameter) *tensor.Tensor {
+	output := tensor.New(normalized.Shape...)
+	for n := range normalized.Shape[0] {
+		outputRow := output.Row(n)
+		for j, x := range normalized.Row(n) {
+			outputRow[j] = x*scale.Value.Data[j] + shift.Value.Data[j]
+		}
+	}
+	return output
+}
+
+// accumulateScaleAndShift adds the gradients of scale and shift and returns
+// the gradient w.r.t the normalized input
+func accumulateScaleAndShift(outputGradient, normalized *tensor.Tensor, scale, shift *Parameter) *tensor.Tensor {
+	normalizedGradient := tensor.New(outputGradient.Shape...)
+	for n := range outputGradient.Shape[0] {
+		normalizedRow, gradientRow := normalized.Row(n), normalizedGradient.Row(n)
+		for j, gradient := range outputGradient.Row(n) {
+			scale.Gradient.Data[j] += gradient * normalizedRow[j]
+			shift.Gradient.Data[j] += gradient
+			gradientRow[j] = gradient * scale.Value.Data[j]
+		}
+	}
+	return normalizedGradient
+}
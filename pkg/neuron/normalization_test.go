@@ -0,0 +1,149 @@
+package neuron
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+	"testing"
+)
+
+func randomizeParameters(layer Layer, rng *rand.Rand) {
+	for _, parameter := range layer.Parameters() {
+		for i := range parameter.Value.Data {
+			parameter.Value.Data[i] = rng.NormFloat64()
+		}
+	}
+}
+
+func TestBatchNormBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(41, 42))
+
+	for _, training := range []bool{true, false} {
+		batchNorm := CreateBatchNorm(5, .9, 1e-5)
+		randomizeParameters(batchNorm, rng)
+		for i := range batchNorm.RunningMean.Data {
+			batchNorm.RunningMean.Data[i] = rng.NormFloat64()
+			batchNorm.RunningVariance.Data[i] = rng.Float64() + .5
+		}
+		batchNorm.SetTraining(training)
+
+		checkLayerGradients(t, batchNorm, randomTensor(rng, 4, 5), rng)
+	}
+}
+
+func TestLayerNormBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(43, 44))
+	layerNorm := CreateLayerNorm(6, 1e-5)
+	randomizeParameters(layerNorm, rng)
+
+	checkLayerGradients(t, layerNorm, randomTensor(rng, 3, 6), rng)
+}
+
+func TestBatchNormNormalizesEveryFeatureOverTheBatch(t *testing.T) {
+	batchNorm := CreateBatchNorm(2, .5, 1e-12)
+	batchNorm.SetTraining(true)
+
+	output := batchNorm.Forward(tensor.FromSlice([]float64{
+		1, 10,
+		3, 30,
+	}, 2, 2))
+
+	expectValues(t, "batch norm", output.Data, []float64{-1, -1, 1, 1})
+	// half of the batch statistics go into the running statistics
+	expectValues(t, "running mean", batchNorm.RunningMean.Data, []float64{1, 10})
+	expectValues(t, "running variance", batchNorm.RunningVariance.Data, []float64{1, 50.5})
+}
+
+func TestBatchNormUsesRunningStatisticsDuringInference(t *testing.T) {
+	batchNorm := CreateBatchNorm(1, .9, 1e-12)
+	batchNorm.RunningMean.Data[0] = 2
+	batchNorm.RunningVariance.Data[0] = 4
+	batchNorm.Scale.Value.Data[0] = 3
+	batchNorm.Shift.Value.Data[0] = 1
+
+	output := batchNorm.Forward(tensor.FromSlice([]float64{6}, 1, 1))
+
+	expectValues(t, "batch norm", output.Data, []float64{3*(6-2)/2 + 1})
+	expectValues(t, "running mean", batchNorm.RunningMean.Data, []float64{2})
+}
+
+func TestLayerNormNormalizesEverySample(t *testing.T) {
+	layerNorm := CreateLayerNorm(4, 1e-12)
+
+	output := layerNorm.Forward(tensor.FromSlice([]float64{
+		1, 2, 3, 4,
+		-5, 5, -5, 5,
+	}, 2, 4))
+
+	for n := range 2 {
+		mean, variance := float64(0), float64(0)
+		for _, x := range output.Row(n) {
+			mean += x / 4
+		}
+		for _, x := range output.Row(n) {
+			variance += (x - mean) * (x - mean) / 4
+		}
+		if math.Abs(mean) > 1e-9 || math.Abs(variance-1) > 1e-9 {
+			t.Errorf("sample %d: expected mean 0 and variance 1 but got %f and %f", n, mean, variance)
+		}
+	}
+}
+
+func TestSaveAndLoadNormalization(t *testing.T) {
+	rng := rand.New(rand.NewPCG(45, 46))
+	ann := CreateANN(func(int) float64 { return rng.NormFloat64() }, 8, 2,
+		WithNormalization(func(size int) Layer { return CreateBatchNorm(size, .8, 1e-4) }))
+	ann.Layers[4] = CreateLayerNorm(2, 1e-3)
+	for _, layer := range ann.Layers {
+		randomizeParameters(layer, rng)
+	}
+	ann.TrainBatch(randomTensor(rng, 4, 8), tensor.New(4, 10), .1)
+
+	buffer := &bytes.Buffer{}
+	if err := SaveJSON(buffer, &ann); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadJSON(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batchNorm := loaded.Layers[1].(*BatchNorm)
+	original := ann.Layers[1].(*BatchNorm)
+	if batchNorm.Momentum != .8 || batchNorm.Epsilon != 1e-4 {
+		t.Errorf("expected momentum 0.8 and epsilon 1e-4 but got %f and %f", batchNorm.Momentum, batchNorm.Epsilon)
+	}
+	if !slices.Equal(batchNorm.RunningVariance.Data, original.RunningVariance.Data) {
+		t.Errorf("expected running variance %v but got %v", original.RunningVariance.Data, batchNorm.RunningVariance.Data)
+	}
+
+	input := randomTensor(rng, 2, 8)
+	if !slices.Equal(loaded.Forward(input).Data, ann.Forward(input).Data) {
+		t.Errorf("expected the loaded model to give the same output")
+	}
+}
+
+func TestBatchNormLetsDeepNetworksLearn(t *testing.T) {
+	rng := rand.New(rand.NewPCG(47, 48))
+	ann := CreateANN(func(fanInSize int) float64 { return rng.NormFloat64() / float64(fanInSize) }, 64, 3,
+		WithNormalization(func(size int) Layer { return CreateBatchNorm(size, .9, 1e-5) }))
+	ann.Optimizer = CreateAdam(.9, .999, 1e-8)
+
+	inputs := randomTensor(rng, 8, 64)
+	expected := tensor.New(8, 10)
+	for n := range 8 {
+		expected.Set(1, n, n)
+	}
+
+	initialLoss, _ := ann.Evaluate(inputs, expected)
+	for range 200 {
+		ann.TrainBatch(inputs, expected, .01)
+	}
+	finalLoss, _ := ann.Evaluate(inputs, expected)
+
+	if finalLoss >= initialLoss/2 {
+		t.Errorf("expected loss to at least halve from %f but was %f", initialLoss, finalLoss)
+	}
+}
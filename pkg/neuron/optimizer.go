@@ -0,0 +1,190 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Optimizer applies the accumulated gradients to the parameters and resets
+// them. optimizers keep state such as momentum for every parameter they have
+// seen, so an optimizer should only be used with one model
+type Optimizer interface {
+	Step(parameters []*Parameter, learningRate float64)
+}
+
+// constructors with default hyper parameters, used to pick an optimizer by name
+var optimizersByName = map[string]func() Optimizer{
+	"sgd":      func() Optimizer { return CreateSGD() },
+	"momentum": func() Optimizer { return CreateMomentum(.9, false) },
+	"nesterov": func() Optimizer { return CreateMomentum(.9, true) },
+	"rmsprop":  func() Optimizer { return CreateRMSProp(.9, 1e-8) },
+	"adam":     func() Optimizer { return CreateAdam(.9, .999, 1e-8) },
+	"adamw":    func() Optimizer { return CreateAdamW(.9, .999, 1e-8, .01) },
+}
+
+func OptimizerNames() []string {
+	names := []string{}
+	for name := range optimizersByName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func CreateOptimizer(name string) (Optimizer, error) {
+	createOptimizer, ok := optimizersByName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown optimizer %q", name)
+	}
+	return createOptimizer(), nil
+}
+
+// SGD subtracts the gradient scaled by the learning rate
+type SGD struct{}
+
+func CreateSGD() *SGD {
+	return &SGD{}
+}
+
+func (optimizer *SGD) Step(parameters []*Parameter, learningRate float64) {
+	UpdateParameters(parameters, learningRate)
+}
+
+// Momentum keeps a velocity that sums past gradients decayed by Momentum.
+// with Nesterov the step looks ahead along the velocity
+type Momentum struct {
+	Momentum float64
+	Nesterov bool
+
+	velocities map[*Parameter][]float64
+}
+
+func CreateMomentum(momentum float64, nesterov bool) *Momentum {
+	return &Momentum{
+		Momentum:   momentum,
+		Nesterov:   nesterov,
+		velocities: map[*Parameter][]float64{},
+	}
+}
+
+func (optimizer *Momentum) Step(parameters []*Parameter, learningRate float64) {
+	for _, parameter := range parameters {
+		velocity := state(optimizer.velocities, parameter)
+
+		for i, gradient := range parameter.Gradient.Data {
+			velocity[i] = optimizer.Momentum*velocity[i] + gradient
+
+			step := velocity[i]
+			if optimizer.Nesterov {
+				step = gradient + optimizer.Momentum*velocity[i]
+			}
+			parameter.Value.Data[i] -= learningRate * step
+			parameter.Gradient.Data[i] = 0
+		}
+	}
+}
+
+// RMSProp divides every gradient by a running average of its magnitude
+type RMSProp struct {
+	Decay   float64
+	Epsilon float64
+
+	squares map[*Parameter][]float64
+}
+
+func CreateRMSProp(decay, epsilon float64) *RMSProp {
+	return &RMSProp{
+		Decay:   decay,
+		Epsilon: epsilon,
+		squares: map[*Parameter][]float64{},
+	}
+}
+
+func (optimizer *RMSProp) Step(parameters []*Parameter, learningRate float64) {
+	for _, parameter := range parameters {
+		squares := state(optimizer.squares, parameter)
+
+		for i, gradient := range parameter.Gradient.Data {
+			squares[i] = optimizer.Decay*squares[i] + (1-optimizer.Decay)*gradient*gradient
+			parameter.Value.Data[i] -= learningRate * gradient / (math.Sqrt(squares[i]) + optimizer.Epsilon)
+			parameter.Gradient.Data[i] = 0
+		}
+	}
+}
+
+// Adam keeps bias corrected running averages of the gradient and its square.
+// a WeightDecay above 0 makes it AdamW, which shrinks the parameters
+// separately from the gradient. like Regularization it leaves parameters
+// alone that are not penalized, such as biases
+type Adam struct {
+	Beta1       float64
+	Beta2       float64
+	Epsilon     float64
+	WeightDecay float64
+
+	moments map[*Parameter]*adamMoments
+}
+
+type adamMoments struct {
+	steps  int
+	first  []float64
+	second []float64
+}
+
+func CreateAdam(beta1, beta2, epsilon float64) *Adam {
+	return CreateAdamW(beta1, beta2, epsilon, 0)
+}
+
+func CreateAdamW(beta1, beta2, epsilon, weightDecay float64) *Adam {
+	return &Adam{
+		Beta1:       beta1,
+		Beta2:       beta2,
+		Epsilon:     epsilon,
+		WeightDecay: weightDecay,
+		moments:     map[*Parameter]*adamMoments{},
+	}
+}
+
+func (optimizer *Adam) Step(parameters []*Parameter, learningRate float64) {
+	for _, parameter := range parameters {
+		moments, ok := optimizer.moments[parameter]
+		if !ok {
+			moments = &adamMoments{
+				first:  make([]float64, parameter.Value.Len()),
+				second: make([]float64, parameter.Value.Len()),
+			}
+			optimizer.moments[parameter] = moments
+		}
+
+		moments.steps++
+		firstCorrection := 1 - math.Pow(optimizer.Beta1, float64(moments.steps))
+		secondCorrection := 1 - math.Pow(optimizer.Beta2, float64(moments.steps))
+		weightDecay := optimizer.WeightDecay
+		if !parameter.Penalize {
+			weightDecay = 0
+		}
+
+		for i, gradient := range parameter.Gradient.Data {
+			moments.first[i] = optimizer.Beta1*moments.first[i] + (1-optimizer.Beta1)*gradient
+			moments.second[i] = optimizer.Beta2*moments.second[i] + (1-optimizer.Beta2)*gradient*gradient
+
+			first := moments.first[i] / firstCorrection
+			second := moments.second[i] / secondCorrection
+
+			parameter.Value.Data[i] -= learningRate * weightDecay * parameter.Value.Data[i]
+			parameter.Value.Data[i] -= learningRate * first / (math.Sqrt(second) + optimizer.Epsilon)
+			parameter.Gradient.Data[i] = 0
+		}
+	}
+}
+
+// state returns the values kept for parameter, starting them at zero
+func state(states map[*Parameter][]float64, parameter *Parameter) []float64 {
+	values, ok := states[parameter]
+	if !ok {
+		values = make([]float64, parameter.Value.Len())
+		states[parameter] = values
+	}
+	return values
+}
@@ -0,0 +1,145 @@
+package neuron
+
+import (
+	"math"
+	"math/rand/v2"
+	"ocr_cnn/pkg/tensor"
+	"testing"
+)
+
+func createTestParameter(values, gradients []float64) *Parameter {
+	return &Parameter{
+		Value:    tensor.FromSlice(values, len(values)),
+		Gradient: tensor.FromSlice(gradients, len(gradients)),
+	}
+}
+
+func expectValues(t *testing.T, name string, actual, expected []float64) {
+	t.Helper()
+	for i := range expected {
+		if math.Abs(actual[i]-expected[i]) > 1e-12 {
+			t.Errorf("%s: value %d expected %f but got %f", name, i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestOptimizersResetGradients(t *testing.T) {
+	for _, name := range OptimizerNames() {
+		optimizer, err := CreateOptimizer(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parameter := createTestParameter([]float64{1, 2}, []float64{.5, -.5})
+
+		optimizer.Step([]*Parameter{parameter}, .1)
+
+		expectValues(t, name, parameter.Gradient.Data, []float64{0, 0})
+	}
+}
+
+func TestCreateOptimizerRejectsUnknownNames(t *testing.T) {
+	if _, err := CreateOptimizer("lbfgs"); err == nil {
+		t.Errorf("expected an error for an unknown optimizer")
+	}
+}
+
+func TestSGDStep(t *testing.T) {
+	parameter := createTestParameter([]float64{1, 2}, []float64{.5, -.5})
+
+	CreateSGD().Step([]*Parameter{parameter}, .1)
+
+	expectValues(t, "sgd", parameter.Value.Data, []float64{1 - .1*.5, 2 + .1*.5})
+}
+
+func TestMomentumAccumulatesVelocity(t *testing.T) {
+	for _, nesterov := range []bool{false, true} {
+		parameter := createTestParameter([]float64{1}, []float64{1})
+		optimizer := CreateMomentum(.9, nesterov)
+
+		optimizer.Step([]*Parameter{parameter}, .1)
+		parameter.Gradient.Data[0] = 1
+		optimizer.Step([]*Parameter{parameter}, .1)
+
+		// velocity is 1 after the first step and 1.9 after the second
+		expected := 1 - .1*1 - .1*1.9
+		if nesterov {
+			expected = 1 - .1*(1+.9*1) - .1*(1+.9*1.9)
+		}
+		expectValues(t, "momentum", parameter.Value.Data, []float64{expected})
+	}
+}
+
+func TestRMSPropScalesByGradientMagnitude(t *testing.T) {
+	parameter := createTestParameter([]float64{0, 0}, []float64{4, -.01})
+
+	CreateRMSProp(.9, 0).Step([]*Parameter{parameter}, .1)
+
+	// the first step divides by sqrt(.1) of the gradient magnitude, whatever its size
+	step := .1 / math.Sqrt(.1)
+	expectValues(t, "rmsprop", parameter.Value.Data, []float64{-step, step})
+}
+
+func TestAdamFirstStepIsTheLearningRate(t *testing.T) {
+	parameter := createTestParameter([]float64{1, 1}, []float64{3, -.002})
+
+	CreateAdam(.9, .999, 0).Step([]*Parameter{parameter}, .01)
+
+	expectValues(t, "adam", parameter.Value.Data, []float64{1 - .01, 1 + .01})
+}
+
+func TestAdamWDecaysWeightsWithoutGradient(t *testing.T) {
+	parameter := createTestParameter([]float64{2}, []float64{0})
+	parameter.Penalize = true
+
+	CreateAdamW(.9, .999, 1e-8, .1).Step([]*Parameter{parameter}, .5)
+
+	expectValues(t, "adamw", parameter.Value.Data, []float64{2 - .5*.1*2})
+}
+
+func TestAdamWLeavesParametersThatAreNotPenalized(t *testing.T) {
+	bias := createTestParameter([]float64{2}, []float64{0})
+
+	CreateAdamW(.9, .999, 1e-8, .1).Step([]*Parameter{bias}, .5)
+
+	expectValues(t, "adamw", bias.Value.Data, []float64{2})
+}
+
+func TestOptimizersKeepStatePerParameter(t *testing.T) {
+	optimizer := CreateMomentum(.9, false)
+	first := createTestParameter([]float64{0}, []float64{1})
+	second := createTestParameter([]float64{0}, []float64{0})
+
+	optimizer.Step([]*Parameter{first, second}, 1)
+
+	if second.Value.Data[0] != 0 {
+		t.Errorf("expected the velocity of one parameter to not move another but got %f", second.Value.Data[0])
+	}
+}
+
+func TestOptimizersReduceLoss(t *testing.T) {
+	for _, name := range OptimizerNames() {
+		rng := rand.New(rand.NewPCG(7, 8))
+		ann := CreateANN(func(fanInSize int) float64 {
+			return rng.NormFloat64() / float64(fanInSize)
+		}, 8, 1)
+		ann.Optimizer, _ = CreateOptimizer(name)
+
+		inputs := tensor.FromSlice([]float64{
+			1, 1, 1, 1, 0, 0, 0, 0,
+			0, 0, 0, 0, 1, 1, 1, 1,
+		}, 2, 8)
+		expected := tensor.New(2, 10)
+		expected.Set(1, 0, 3)
+		expected.Set(1, 1, 8)
+
+		initialLoss, _ := ann.Evaluate(inputs, expected)
+		for range 20 {
+			ann.TrainBatch(inputs, expected, .01)
+		}
+		finalLoss, _ := ann.Evaluate(inputs, expected)
+
+		if finalLoss >= initialLoss {
+			t.Errorf("%s: expected loss to decrease from %f but was %f", name, initialLoss, finalLoss)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package neuron
+
+import (
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+// poolingWindow describes a Size x Size window that moves Stride pixels at a
+// time over every channel of a channel x height x width feature map
+type poolingWindow struct {
+	Channels    int
+	InputHeight int
+	InputWidth  int
+	Size        int
+	Stride      int
+}
+
+func createPoolingWindow(channels, inputHeight, inputWidth, size, stride int) poolingWindow {
+	window := poolingWindow{
+		Channels:    channels,
+		InputHeight: inputHeight,
+		InputWidth:  inputWidth,
+		Size:        size,
+		Stride:      stride,
+	}
+
+	if size <= 0 || stride <= 0 || window.OutputHeight() <= 0 || window.OutputWidth() <= 0 {
+		common.PrintAndTerminate(fmt.Sprintf("invalid pooling size: %d stride: %d for input %dx%d", size, stride, inputHeight, inputWidth))
+	}
+
+	return window
+}
+
+func (window poolingWindow) OutputHeight() int {
+	return (window.InputHeight-window.Size)/window.Stride + 1
+}
+
+func (window poolingWindow) OutputWidth() int {
+	return (window.InputWidth-window.Size)/window.Stride + 1
+}
+
+func (window poolingWindow) OutputSize() int {
+	return window.Channels * window.OutputHeight() * window.OutputWidth()
+}
+
+func (window poolingWindow) inputSize() int {
+	return window.Channels * window.InputHeight * window.InputWidth
+}
+
+// calls pool once per output value of a sample with the input indices that fall inside its window
+func (window poolingWindow) each(pool func(outputIdx int, inputIdxs []int)) {
+	outputHeight, outputWidth := window.OutputHeight(), window.OutputWidth()
+	inputIdxs := make([]int, 0, window.Size*window.Size)
+
+	for c := range window.Channels {
+		for oy := range outputHeight {
+			for ox := range outputWidth {
+				inputIdxs = inputIdxs[:0]
+				for ky := range window.Size {
+					for kx := range window.Size {
+						y := oy*window.Stride + ky
+						x := ox*window.Stride + kx
+						inputIdxs = append(inputIdxs, (c*window.InputHeight+y)*window.InputWidth+x)
+					}
+				}
+
+				pool((c*outputHeight+oy)*outputWidth+ox, inputIdxs)
+			}
+		}
+	}
+}
+
+// MaxPool2D keeps the largest value of every window. the gradient flows
+// back only to the input that was selected
+type MaxPool2D struct {
+	poolingWindow
+
+	selected [][]int // input index chosen for every output of every sample, cached by Forward
+}
+
+func CreateMaxPool2D(channels, inputHeight, inputWidth, size, stride int) *MaxPool2D {
+	return &MaxPool2D{
+		poolingWindow: createPoolingWindow(channels, inputHeight, inputWidth, size, stride),
+	}
+}
+
+func (pool *MaxPool2D) Forward(input *tensor.Tensor) *tensor.Tensor {
+	checkFeatures(input, pool.inputSize(), "max pooling")
+
+	batchSize := input.Shape[0]
+	output := tensor.New(batchSize, pool.OutputSize())
+	pool.selected = make([][]int, batchSize)
+
+	for n := range batchSize {
+		inputRow, outputRow := input.Row(n), output.Row(n)
+		selectedRow := make([]int, pool.OutputSize())
+
+		pool.each(func(outputIdx int, inputIdxs []int) {
+			selected := inputIdxs[0]
+			for _, inputIdx := range inputIdxs[1:] {
+				if inputRow[inputIdx] > inputRow[selected] {
+					selected = inputIdx
+				}
+			}
+
+			selectedRow[outputIdx] = selected
+			outputRow[outputIdx] = inputRow[selected]
+		})
+
+		pool.selected[n] = selectedRow
+	}
+
+	return output
+}
+
+func (pool *MaxPool2D) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	batchSize := outputGradient.Shape[0]
+	inputGradient := tensor.New(batchSize, pool.inputSize())
+
+	for n := range batchSize {
+		inputRow := inputGradient.Row(n)
+		for outputIdx, gradient := range outputGradient.Row(n) {
+			inputRow[pool.selected[n][outputIdx]] += gradient
+		}
+	}
+
+	return inputGradient
+}
+
+func (pool *MaxPool2D) Parameters() []*Parameter {
+	return nil
+}
+
+// AvgPool2D keeps the mean of every window. the gradient is shared
+// equally between every input of the window
+type AvgPool2D struct {
+	poolingWindow
+}
+
+func CreateAvgPool2D(channels, inputHeight, inputWidth, size, stride int) *AvgPool2D {
+	return &AvgPool2D{
+		poolingWindow: createPoolingWindow(channels, inputHeight, inputWidth, size, stride),
+	}
+}
+
+func (pool *AvgPool2D) Forward(input *tensor.Tensor) *tensor.Tensor {
+	checkFeatures(input, pool.inputSize(), "average pooling")
+
+	batchSize := input.Shape[0]
+	output := tensor.New(batchSize, pool.OutputSize())
+
+	for n := range batchSize {
+		inputRow, outputRow := input.Row(n), output.Row(n)
+
+		pool.each(func(outputIdx int, inputIdxs []int) {
+			sum := float64(0)
+			for _, inputIdx := range inputIdxs {
+				sum += inputRow[inputIdx]
+			}
+
+			outputRow[outputIdx] = sum / float64(len(inputIdxs))
+		})
+	}
+
+	return output
+}
+
+func (pool *AvgPool2D) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	batchSize := outputGradient.Shape[0]
+	inputGradient := tensor.New(batchSize, pool.inputSize())
+
+	for n := range batchSize {
+		inputRow, outputRow := inputGradient.Row(n), outputGradient.Row(n)
+
+		pool.each(func(outputIdx int, inputIdxs []int) {
+			share := outputRow[outputIdx] / float64(len(inputIdxs))
+			for _, inputIdx := range inputIdxs {
+				inputRow[inputIdx] += share
+			}
+		})
+	}
+
+	return inputGradient
+}
+
+func (pool *AvgPool2D) Parameters() []*Parameter {
+	return nil
+}
@@ -0,0 +1,88 @@
+package neuron
+
+import (
+	"math/rand/v2"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+	"testing"
+)
+
+func TestMaxPool2DSelectsLargestValueOfEveryWindow(t *testing.T) {
+	pool := CreateMaxPool2D(1, 4, 4, 2, 2)
+	input := tensor.FromSlice([]float64{
+		1, 2, 5, 0,
+		3, 4, 1, 1,
+		0, 0, 9, 8,
+		7, 0, 6, 7,
+	}, 1, 16)
+
+	output := pool.Forward(input)
+
+	expected := []float64{4, 5, 7, 9}
+	if !slices.Equal(output.Data, expected) {
+		t.Errorf("expected %v but got %v", expected, output.Data)
+	}
+}
+
+func TestMaxPool2DRoutesGradientToSelectedInput(t *testing.T) {
+	pool := CreateMaxPool2D(1, 4, 4, 2, 2)
+	input := tensor.FromSlice([]float64{
+		1, 2, 5, 0,
+		3, 4, 1, 1,
+		0, 0, 9, 8,
+		7, 0, 6, 7,
+	}, 1, 16)
+
+	pool.Forward(input)
+	inputGradient := pool.Backward(tensor.FromSlice([]float64{.1, .2, .3, .4}, 1, 4))
+
+	expected := []float64{
+		0, 0, .2, 0,
+		0, .1, 0, 0,
+		0, 0, .4, 0,
+		.3, 0, 0, 0,
+	}
+	if !slices.Equal(inputGradient.Data, expected) {
+		t.Errorf("expected %v but got %v", expected, inputGradient.Data)
+	}
+}
+
+func TestAvgPool2DAveragesEveryWindowAndSharesGradient(t *testing.T) {
+	pool := CreateAvgPool2D(2, 2, 2, 2, 2)
+	input := tensor.FromSlice([]float64{
+		1, 2,
+		3, 4,
+
+		5, 6,
+		7, 8,
+	}, 1, 8)
+
+	output := pool.Forward(input)
+	if !slices.Equal(output.Data, []float64{2.5, 6.5}) {
+		t.Errorf("expected [2.5 6.5] but got %v", output.Data)
+	}
+
+	inputGradient := pool.Backward(tensor.FromSlice([]float64{.4, .8}, 1, 2))
+	expected := []float64{.1, .1, .1, .1, .2, .2, .2, .2}
+	if !slices.Equal(inputGradient.Data, expected) {
+		t.Errorf("expected %v but got %v", expected, inputGradient.Data)
+	}
+}
+
+func TestPoolingOutputSizeWithOverlappingWindows(t *testing.T) {
+	pool := CreateMaxPool2D(3, 5, 7, 3, 2)
+
+	if pool.OutputHeight() != 2 || pool.OutputWidth() != 3 {
+		t.Errorf("expected 2x3 output but got %dx%d", pool.OutputHeight(), pool.OutputWidth())
+	}
+	if pool.OutputSize() != 3*2*3 {
+		t.Errorf("expected output size %d but got %d", 3*2*3, pool.OutputSize())
+	}
+}
+
+func TestPoolingBackwardMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+
+	checkLayerGradients(t, CreateMaxPool2D(2, 5, 5, 3, 2), randomTensor(rng, 2, 2*5*5), rng)
+	checkLayerGradients(t, CreateAvgPool2D(2, 5, 5, 3, 2), randomTensor(rng, 2, 2*5*5), rng)
+}
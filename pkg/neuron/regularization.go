@@ -0,0 +1,89 @@
+package neuron
+
+import "math"
+
+// Regularization penalizes large weights and limits the gradients applied by
+// Update. every field is disabled by 0
+type Regularization struct {
+	L1 float64 // adds L1 x sum(|w|) to the loss
+	L2 float64 // adds L2 / 2 x sum(w²) to the loss, so the gradient is L2 x w
+
+	ClipValue float64 // largest magnitude of any single gradient
+	ClipNorm  float64 // largest L2 norm of all gradients together
+}
+
+// Penalty is the loss the L1 and L2 terms add for the penalized parameters
+func (regularization Regularization) Penalty(parameters []*Parameter) float64 {
+	if regularization.L1 == 0 && regularization.L2 == 0 {
+		return 0
+	}
+
+	penalty := float64(0)
+	for _, parameter := range parameters {
+		if !parameter.Penalize {
+			continue
+		}
+		for _, w := range parameter.Value.Data {
+			penalty += regularization.L1*math.Abs(w) + regularization.L2/2*w*w
+		}
+	}
+	return penalty
+}
+
+// apply adds the gradients of the penalty and then clips, first by value and
+// then by norm. it returns the global norm of the gradients before clipping
+func (regularization Regularization) apply(parameters []*Parameter) float64 {
+	if regularization.L1 != 0 || regularization.L2 != 0 {
+		for _, parameter := range parameters {
+			if !parameter.Penalize {
+				continue
+			}
+			for i, w := range parameter.Value.Data {
+				sign := float64(0)
+				if w > 0 {
+					sign = 1
+				} else if w < 0 {
+					sign = -1
+				}
+				parameter.Gradient.Data[i] += regularization.L1*sign + regularization.L2*w
+			}
+		}
+	}
+
+	norm := GradientNorm(parameters)
+
+	if regularization.ClipValue > 0 {
+		for _, parameter := range parameters {
+			for i, gradient := range parameter.Gradient.Data {
+				parameter.Gradient.Data[i] = max(-regularization.ClipValue, min(regularization.ClipValue, gradient))
+			}
+		}
+	}
+
+	if regularization.ClipNorm > 0 {
+		if clippedNorm := GradientNorm(parameters); clippedNorm > regularization.ClipNorm {
+			ScaleGradients(parameters, regularization.ClipNorm/clippedNorm)
+		}
+	}
+
+	return norm
+}
+
+// GradientNorm is the L2 norm of the gradients of all parameters together
+func GradientNorm(parameters []*Parameter) float64 {
+	sum := float64(0)
+	for _, parameter := range parameters {
+		for _, gradient := range parameter.Gradient.Data {
+			sum += gradient * gradient
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+func ScaleGradients(parameters []*Parameter, factor float64) {
+	for _, parameter := range parameters {
+		for i := range parameter.Gradient.Data {
+			parameter.Gradient.Data[i] *= factor
+		}
+	}
+}
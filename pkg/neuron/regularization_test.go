@@ -0,0 +1,120 @@
+package neuron
+
+import (
+	"math"
+	"math/rand/v2"
+	"ocr_cnn/pkg/tensor"
+	"testing"
+)
+
+func TestRegularizationPenalizesOnlyWeights(t *testing.T) {
+	weights := createTestParameter([]float64{1, -2}, []float64{0, 0})
+	weights.Penalize = true
+	biases := createTestParameter([]float64{3}, []float64{0})
+
+	regularization := Regularization{L1: .1, L2: .5}
+
+	penalty := regularization.Penalty([]*Parameter{weights, biases})
+	if expected := .1*(1+2) + .5/2*(1+4); math.Abs(penalty-expected) > 1e-12 {
+		t.Errorf("expected penalty %f but got %f", expected, penalty)
+	}
+
+	regularization.apply([]*Parameter{weights, biases})
+	expectValues(t, "weight gradients", weights.Gradient.Data, []float64{.1 + .5*1, -.1 + .5*-2})
+	expectValues(t, "bias gradients", biases.Gradient.Data, []float64{0})
+}
+
+func TestRegularizationPenaltyGradientMatchesFiniteDifferences(t *testing.T) {
+	weights := createTestParameter([]float64{.7, -1.3, .2}, []float64{0, 0, 0})
+	weights.Penalize = true
+	regularization := Regularization{L1: .3, L2: .4}
+	regularization.apply([]*Parameter{weights})
+
+	const epsilon = 1e-6
+	for i := range weights.Value.Data {
+		original := weights.Value.Data[i]
+		weights.Value.Data[i] = original + epsilon
+		plus := regularization.Penalty([]*Parameter{weights})
+		weights.Value.Data[i] = original - epsilon
+		minus := regularization.Penalty([]*Parameter{weights})
+		weights.Value.Data[i] = original
+
+		if expected := (plus - minus) / (2 * epsilon); relativeError(weights.Gradient.Data[i], expected) > 1e-6 {
+			t.Errorf("weight %d: expected gradient %f but got %f", i, expected, weights.Gradient.Data[i])
+		}
+	}
+}
+
+func TestClipByValue(t *testing.T) {
+	parameter := createTestParameter([]float64{0, 0, 0}, []float64{-5, .5, 5})
+
+	norm := Regularization{ClipValue: 1}.apply([]*Parameter{parameter})
+
+	expectValues(t, "gradients", parameter.Gradient.Data, []float64{-1, .5, 1})
+	if math.Abs(norm-math.Sqrt(50.25)) > 1e-12 {
+		t.Errorf("expected the norm before clipping %f but got %f", math.Sqrt(50.25), norm)
+	}
+}
+
+func TestClipByGlobalNorm(t *testing.T) {
+	first := createTestParameter([]float64{0}, []float64{3})
+	second := createTestParameter([]float64{0}, []float64{4})
+
+	Regularization{ClipNorm: 1}.apply([]*Parameter{first, second})
+
+	expectValues(t, "gradients", []float64{first.Gradient.Data[0], second.Gradient.Data[0]}, []float64{.6, .8})
+
+	// gradients already within the norm are left alone
+	Regularization{ClipNorm: 2}.apply([]*Parameter{first, second})
+	expectValues(t, "gradients", []float64{first.Gradient.Data[0], second.Gradient.Data[0]}, []float64{.6, .8})
+}
+
+func TestTrainBatchLossIncludesThePenalty(t *testing.T) {
+	ann := CreateANN(func(int) float64 { return .1 }, 4, 1)
+	inputs := tensor.FromSlice([]float64{1, 0, 1, 0, 0, 1, 0, 1}, 2, 4)
+	expected := tensor.New(2, 10)
+	expected.Fill(.1)
+
+	unregularized, _ := ann.Evaluate(inputs, expected)
+	ann.Regularization = Regularization{L2: .01}
+	regularized, _ := ann.Evaluate(inputs, expected)
+
+	if expectedLoss := unregularized + 2*ann.Penalty(); math.Abs(regularized-expectedLoss) > 1e-12 {
+		t.Errorf("expected loss %f but got %f", expectedLoss, regularized)
+	}
+}
+
+func TestClippingBoundsEveryUpdate(t *testing.T) {
+	rng := rand.New(rand.NewPCG(49, 50))
+	ann := CreateANN(func(int) float64 { return rng.NormFloat64() * 1e3 }, 32, 2)
+	ann.Regularization = Regularization{ClipNorm: .5}
+
+	inputs := randomTensor(rng, 4, 32)
+	expected := tensor.New(4, 10)
+	for n := range 4 {
+		expected.Set(1, n, n)
+	}
+
+	for range 5 {
+		before := []float64{}
+		for _, parameter := range ann.Parameters() {
+			before = append(before, parameter.Value.Data...)
+		}
+
+		ann.TrainBatch(inputs, expected, 2)
+
+		change, i := float64(0), 0
+		for _, parameter := range ann.Parameters() {
+			for _, value := range parameter.Value.Data {
+				change += (value - before[i]) * (value - before[i])
+				i++
+			}
+		}
+		if math.Sqrt(change) > 2*.5+1e-9 {
+			t.Errorf("expected SGD to move the parameters by at most 1 but moved %f", math.Sqrt(change))
+		}
+		if ann.GradientNorm() <= .5 {
+			t.Errorf("expected the unclipped gradient norm to be large but got %f", ann.GradientNorm())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package neuron
+
+import "math"
+
+// Scheduler picks the learning rate of every epoch, counting epochs from 0.
+// it is independent of the Optimizer the rate is given to
+type Scheduler interface {
+	LearningRate(epoch int) float64
+}
+
+// LossObserver is implemented by schedulers that react to the validation
+// loss, which is given to them at the end of every epoch
+type LossObserver interface {
+	ObserveLoss(loss float64)
+}
+
+type ConstantRate struct {
+	Rate float64
+}
+
+func (schedule *ConstantRate) LearningRate(int) float64 {
+	return schedule.Rate
+}
+
+// StepDecay multiplies the rate by Factor every StepSize epochs
+type StepDecay struct {
+	Initial  float64
+	Factor   float64
+	StepSize int
+}
+
+func CreateStepDecay(initial, factor float64, stepSize int) *StepDecay {
+	return &StepDecay{Initial: initial, Factor: factor, StepSize: max(stepSize, 1)}
+}
+
+func (schedule *StepDecay) LearningRate(epoch int) float64 {
+	return schedule.Initial * math.Pow(schedule.Factor, float64(epoch/schedule.StepSize))
+}
+
+// ExponentialDecay multiplies the rate by Decay every epoch
+type ExponentialDecay struct {
+	Initial float64
+	Decay   float64
+}
+
+func (schedule *ExponentialDecay) LearningRate(epoch int) float64 {
+	return schedule.Initial * math.Pow(schedule.Decay, float64(epoch))
+}
+
+// CosineAnnealing lowers the rate from Initial to Minimum along half a cosine
+// over Period epochs and then restarts. every restart the period is
+// multiplied by PeriodMultiplier
+type CosineAnnealing struct {
+	Initial          float64
+	Minimum          float64
+	Period           int
+	PeriodMultiplier int
+}
+
+func CreateCosineAnnealing(initial, minimum float64, period, periodMultiplier int) *CosineAnnealing {
+	return &CosineAnnealing{
+		Initial:          initial,
+		Minimum:          minimum,
+		Period:           max(period, 1),
+		PeriodMultiplier: max(periodMultiplier, 1),
+	}
+}
+
+func (schedule *CosineAnnealing) LearningRate(epoch int) float64 {
+	period := schedule.Period
+	for epoch >= period { // skip the finished periods
+		epoch -= period
+		period *= schedule.PeriodMultiplier
+	}
+
+	progress := float64(epoch) / float64(period)
+	return schedule.Minimum + (schedule.Initial-schedule.Minimum)*(1+math.Cos(math.Pi*progress))/2
+}
+
+// Warmup raises the rate linearly over the first Epochs epochs up to the
+// first rate of After, which then takes over and only sees the losses of
+// the epochs after the warmup
+type Warmup struct {
+	Epochs int
+	After  Scheduler
+
+	observedEpochs int
+}
+
+func (schedule *Warmup) LearningRate(epoch int) float64 {
+	if epoch < schedule.Epochs {
+		return schedule.After.LearningRate(0) * float64(epoch+1) / float64(schedule.Epochs+1)
+	}
+	return schedule.After.LearningRate(epoch - schedule.Epochs)
+}
+
+func (schedule *Warmup) ObserveLoss(loss float64) {
+	schedule.observedEpochs++
+	if schedule.observedEpochs <= schedule.Epochs {
+		return
+	}
+	if observer, ok := schedule.After.(LossObserver); ok {
+		observer.ObserveLoss(loss)
+	}
+}
+
+// ReduceOnPlateau multiplies the rate by Factor once the loss has not
+// improved for more than Patience epochs, but never below Minimum
+type ReduceOnPlateau struct {
+	Rate     float64
+	Factor   float64
+	Patience int
+	Minimum  float64
+
+	best            float64
+	epochsSinceBest int
+}
+
+func CreateReduceOnPlateau(initial, factor float64, patience int, minimum float64) *ReduceOnPlateau {
+	return &ReduceOnPlateau{
+		Rate:     initial,
+		Factor:   factor,
+		Patience: patience,
+		Minimum:  minimum,
+		best:     math.Inf(1),
+	}
+}
+
+func (schedule *ReduceOnPlateau) LearningRate(int) float64 {
+	return schedule.Rate
+}
+
+func (schedule *ReduceOnPlateau) ObserveLoss(loss float64) {
+	if loss < schedule.best {
+		schedule.best = loss
+		schedule.epochsSinceBest = 0
+		return
+	}
+
+	schedule.epochsSinceBest++
+	if schedule.epochsSinceBest > schedule.Patience {
+		schedule.Rate = max(schedule.Rate*schedule.Factor, schedule.Minimum)
+		schedule.epochsSinceBest = 0
+	}
+}
@@ -0,0 +1,78 @@
+package neuron
+
+import (
+	"math"
+	"testing"
+)
+
+func learningRates(schedule Scheduler, epochs int) []float64 {
+	rates := []float64{}
+	for epoch := range epochs {
+		rates = append(rates, schedule.LearningRate(epoch))
+	}
+	return rates
+}
+
+func TestStepDecay(t *testing.T) {
+	rates := learningRates(CreateStepDecay(1, .5, 2), 5)
+	expectValues(t, "step decay", rates, []float64{1, 1, .5, .5, .25})
+}
+
+func TestExponentialDecay(t *testing.T) {
+	rates := learningRates(&ExponentialDecay{Initial: 1, Decay: .9}, 3)
+	expectValues(t, "exponential decay", rates, []float64{1, .9, .81})
+}
+
+func TestCosineAnnealingRestarts(t *testing.T) {
+	rates := learningRates(CreateCosineAnnealing(1, 0, 2, 2), 7)
+
+	// a period of 2 epochs, then a period of 4
+	expected := []float64{1, .5, 1, (1 + math.Cos(math.Pi/4)) / 2, .5, (1 + math.Cos(3*math.Pi/4)) / 2, 1}
+	expectValues(t, "cosine annealing", rates, expected)
+}
+
+func TestWarmupRisesToTheNextSchedule(t *testing.T) {
+	schedule := &Warmup{Epochs: 3, After: CreateStepDecay(.4, .5, 1)}
+
+	rates := learningRates(schedule, 5)
+	expectValues(t, "warmup", rates, []float64{.1, .2, .3, .4, .2})
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	schedule := CreateReduceOnPlateau(1, .5, 1, .3)
+
+	rates := []float64{}
+	for _, loss := range []float64{3, 2, 2.5, 2.1, 2.2, 2.2, 2.2, 1} {
+		rates = append(rates, schedule.LearningRate(0))
+		schedule.ObserveLoss(loss)
+	}
+
+	// 2 epochs without improving halve the rate, until it reaches the minimum
+	expectValues(t, "reduce on plateau", rates, []float64{1, 1, 1, 1, .5, .5, .3, .3})
+}
+
+func TestWarmupPassesLossToTheNextSchedule(t *testing.T) {
+	plateau := CreateReduceOnPlateau(1, .5, 0, 0)
+	schedule := &Warmup{Epochs: 1, After: plateau}
+
+	schedule.ObserveLoss(1)
+	schedule.ObserveLoss(2)
+	schedule.ObserveLoss(3)
+
+	if schedule.LearningRate(1) != .5 {
+		t.Errorf("expected the plateau to lower the rate to .5 but got %f", schedule.LearningRate(1))
+	}
+}
+
+func TestWarmupKeepsItsLossesFromThePlateau(t *testing.T) {
+	schedule := &Warmup{Epochs: 2, After: CreateReduceOnPlateau(1, .5, 1, 0)}
+
+	rates := []float64{}
+	for epoch, loss := range []float64{1, 1.5, 2, 1.9, 1.8} {
+		rates = append(rates, schedule.LearningRate(epoch))
+		schedule.ObserveLoss(loss)
+	}
+
+	// the losses after the warmup keep improving, however low the warmup ones were
+	expectValues(t, "warmup then plateau", rates, []float64{1. / 3, 2. / 3, 1, 1, 1})
+}
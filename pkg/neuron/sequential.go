@@ -0,0 +1,118 @@
+package neuron
+
+import (
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+// Sequential feeds the output of every layer into the next one. it is a
+// Layer itself so models can be nested
+type Sequential struct {
+	Layers         []Layer
+	Optimizer      Optimizer // applies the gradients in Update, plain SGD when nil
+	Regularization Regularization
+
+	training     bool
+	gradientNorm float64
+	output       *tensor.Tensor
+}
+
+func CreateSequential(layers ...Layer) *Sequential {
+	return &Sequential{Layers: layers}
+}
+
+// SetTraining switches every layer between training and inference. models
+// start out in inference mode
+func (model *Sequential) SetTraining(training bool) {
+	model.training = training
+	for _, layer := range model.Layers {
+		if layer, ok := layer.(TrainingMode); ok {
+			layer.SetTraining(training)
+		}
+	}
+}
+
+func (model *Sequential) Training() bool {
+	return model.training
+}
+
+func (model *Sequential) Forward(input *tensor.Tensor) *tensor.Tensor {
+	output := input
+	for _, layer := range model.Layers {
+		output = layer.Forward(output)
+	}
+
+	model.output = output
+	return output
+}
+
+func (model *Sequential) Backward(outputGradient *tensor.Tensor) *tensor.Tensor {
+	return backwardThrough(model.Layers, outputGradient)
+}
+
+func (model *Sequential) Parameters() []*Parameter {
+	parameters := []*Parameter{}
+	for _, layer := range model.Layers {
+		parameters = append(parameters, layer.Parameters()...)
+	}
+	return parameters
+}
+
+// Update adds the Regularization penalty gradients, clips, and applies the gradients
+func (model *Sequential) Update(learningRate float64) {
+	parameters := model.Parameters()
+	model.gradientNorm = model.Regularization.apply(parameters)
+
+	if model.Optimizer == nil {
+		UpdateParameters(parameters, learningRate)
+		return
+	}
+	model.Optimizer.Step(parameters, learningRate)
+}
+
+// GradientNorm is the norm of the gradients of the last Update before clipping
+func (model *Sequential) GradientNorm() float64 {
+	return model.gradientNorm
+}
+
+// Penalty is the loss Regularization adds for the current weights
+func (model *Sequential) Penalty() float64 {
+	return model.Regularization.Penalty(model.Parameters())
+}
+
+// BackwardPropagation accumulates the cross entropy gradients of the last
+// Forward call, updates every parameter and returns the gradient w.r.t the input
+func (model *Sequential) BackwardPropagation(expectedOneHotEncodings *tensor.Tensor, learningRate float64) *tensor.Tensor {
+	inputGradient := model.AccumulateGradients(expectedOneHotEncodings)
+	model.Update(learningRate)
+	return inputGradient
+}
+
+// AccumulateGradients adds the cross entropy gradients of the last Forward call
+// to every parameter without applying them. gradients of every sample in the
+// batch are summed
+func (model *Sequential) AccumulateGradients(expectedOneHotEncodings *tensor.Tensor) *tensor.Tensor {
+	layers := model.Layers
+	gradient := tensor.New(model.output.Shape...)
+
+	lossGradient := common.CrossEntropyPartialDerivative
+	if _, ok := layers[len(layers)-1].(*Softmax); ok {
+		// softmax and cross entropy combine into a simpler and more stable gradient
+		lossGradient = common.SoftmaxCrossEntropyGradient
+		layers = layers[:len(layers)-1]
+	}
+
+	for n := range gradient.Shape[0] {
+		copy(gradient.Row(n), lossGradient(model.output.Row(n), expectedOneHotEncodings.Row(n)))
+	}
+
+	return backwardThrough(layers, gradient)
+}
+
+func backwardThrough(layers []Layer, outputGradient *tensor.Tensor) *tensor.Tensor {
+	gradient := outputGradient
+	for i := len(layers) - 1; i >= 0; i-- {
+		gradient = layers[i].Backward(gradient)
+	}
+	return gradient
+}
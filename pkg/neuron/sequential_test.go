@@ -0,0 +1,172 @@
+package neuron
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+	"testing"
+)
+
+func TestCreateANNStacksDenseLayers(t *testing.T) {
+	ann := CreateANN(func(int) float64 { return 1.2 }, 8, 2)
+
+	if sizes := ann.LayerSizes(); !slices.Equal(sizes, []int{8, 4, 2, 10}) {
+		t.Errorf("expected layer sizes [8 4 2 10] but got %v", sizes)
+	}
+
+	expectedLayers := []string{"*neuron.Dense", "*neuron.ActivationLayer", "*neuron.Dense", "*neuron.ActivationLayer", "*neuron.Dense", "*neuron.Softmax"}
+	if len(ann.Layers) != len(expectedLayers) {
+		t.Fatalf("expected %d layers but got %d", len(expectedLayers), len(ann.Layers))
+	}
+	for i, layer := range ann.Layers {
+		if actual := fmt.Sprintf("%T", layer); actual != expectedLayers[i] {
+			t.Errorf("layer %d: expected %s but got %s", i, expectedLayers[i], actual)
+		}
+	}
+}
+
+func TestGraphViewMatchesANN(t *testing.T) {
+	count := 0
+	ann := CreateANN(func(int) float64 {
+		count++
+		return float64(count%7)/10 - .3
+	}, 4, 1)
+
+	ann.SetInput([]float64{1, 0, 1, 1})
+	expectedLogits := slices.Clone(ann.ForwardPropagation())
+
+	graph := ann.Graph()
+	for i, neuron := range graph.OutputLayer {
+		if neuron.Activation != ann.OutputVector()[i] {
+			t.Errorf("output %d: expected view activation %f but got %f", i, ann.OutputVector()[i], neuron.Activation)
+		}
+	}
+
+	logits := graph.ForwardPropagation()
+	if !slices.Equal(logits, expectedLogits) {
+		t.Errorf("expected graph logits %v but got %v", expectedLogits, logits)
+	}
+}
+
+func TestGraphViewKeepsTheActivations(t *testing.T) {
+	for _, name := range []string{"tanh", "gelu", "leaky_relu"} {
+		count := 0
+		randomFunc := func(int) float64 {
+			count++
+			return float64(count%7)/10 - .3
+		}
+		ann := CreateANN(randomFunc, 4, 2, WithHiddenActivations(func() Layer {
+			activation, _ := CreateActivation(name)
+			return activation
+		}))
+
+		input := []float64{1, -.5, .3, 1}
+		ann.SetInput(input)
+		expectedLogits := slices.Clone(ann.ForwardPropagation())
+
+		graph := ann.Graph()
+		if logits := graph.ForwardPropagation(); !slices.Equal(logits, expectedLogits) {
+			t.Errorf("%s: expected graph logits %v but got %v", name, expectedLogits, logits)
+		}
+
+		target := []float64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0}
+		graphGradient := graph.BackwardPropagation(target, .1)
+		expectedGradient := ann.BackwardPropagation(target, .1)
+		for i := range expectedGradient {
+			if relativeError(graphGradient[i], expectedGradient[i]) > 1e-12 {
+				t.Errorf("%s: expected input gradient %v but got %v", name, expectedGradient, graphGradient)
+				break
+			}
+		}
+	}
+}
+
+func TestGraphPrintFollowsLayerOrder(t *testing.T) {
+	count := 0
+	ann := CreateANN(func(int) float64 {
+		count++
+		return float64(count%5)/10 - .2
+	}, 4, 1)
+	ann.SetInput([]float64{.1, .2, .3, .4})
+	ann.ForwardPropagation()
+	graph := ann.Graph()
+
+	lines := []string{}
+	graph.Print(func(line string) { lines = append(lines, line) })
+
+	if len(lines) != 3 {
+		t.Fatalf("expected input, hidden and output lines but got %d", len(lines))
+	}
+	if lines[0] != "Neuron(0.100000) | Neuron(0.200000) | Neuron(0.300000) | Neuron(0.400000) | " {
+		t.Errorf("expected input neurons in order but got %s", lines[0])
+	}
+	for range 10 {
+		again := []string{}
+		graph.Print(func(line string) { again = append(again, line) })
+		if !slices.Equal(lines, again) {
+			t.Fatalf("expected every print to be the same")
+		}
+	}
+}
+
+func TestSequentialAccumulateGradientsMatchesFiniteDifferenceGradients(t *testing.T) {
+	rng := rand.New(rand.NewPCG(15, 16))
+	randomFunc := func(fanInSize int) float64 {
+		return rng.NormFloat64() / float64(fanInSize)
+	}
+	model := CreateSequential(
+		CreateConv2D(randomFunc, 1, 6, 6, 2, 3, 1, 1),
+		CreateReLU(),
+		CreateMaxPool2D(2, 6, 6, 2, 2),
+		CreateDense(randomFunc, 2*3*3, 5),
+		CreateReLU(),
+		CreateDense(randomFunc, 5, 10),
+		CreateSoftmax(),
+	)
+	for _, parameter := range model.Parameters() {
+		for i := range parameter.Value.Data {
+			parameter.Value.Data[i] += rng.Float64() / 10
+		}
+	}
+
+	input := randomTensor(rng, 2, 6*6)
+	expectedOneHotEncodings := tensor.New(2, 10)
+	expectedOneHotEncodings.Set(1, 0, 2)
+	expectedOneHotEncodings.Set(1, 1, 7)
+
+	if check := CheckModelGradients(model, input, expectedOneHotEncodings); check.MaxRelativeError > 1e-5 {
+		t.Error(check)
+	}
+}
+
+func TestSequentialBackwardPropagationReducesLoss(t *testing.T) {
+	rng := rand.New(rand.NewPCG(17, 18))
+	ann := CreateANN(func(fanInSize int) float64 {
+		return rng.NormFloat64() / float64(fanInSize)
+	}, 16, 2)
+
+	input := randomTensor(rng, 1, 16)
+	expectedOneHotEncoding := tensor.New(1, 10)
+	expectedOneHotEncoding.Set(1, 0, 4)
+
+	initialLoss := common.CrossEntropyLoss(expectedOneHotEncoding.Data, ann.Forward(input).Data)
+	for range 20 {
+		ann.Forward(input)
+		ann.Sequential.BackwardPropagation(expectedOneHotEncoding, .1)
+	}
+	finalLoss := common.CrossEntropyLoss(expectedOneHotEncoding.Data, ann.Forward(input).Data)
+
+	if finalLoss >= initialLoss {
+		t.Errorf("expected loss to decrease from %f but was %f", initialLoss, finalLoss)
+	}
+
+	for _, parameter := range ann.Parameters() {
+		for _, gradient := range parameter.Gradient.Data {
+			if gradient != 0 {
+				t.Fatalf("expected gradients to be reset after an update")
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package neuron
+
+import (
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+)
+
+// TrainBatch runs a mini-batch through the model in training mode,
+// accumulates the gradient of every sample and applies their mean. it returns
+// the summed loss, the cross entropy of every sample plus the regularization
+// penalty, and the number of samples whose most probable class was correct.
+// the model goes back to its previous mode afterwards
+func (ann *ANN) TrainBatch(inputs, expectedOneHotEncodings *tensor.Tensor, learningRate float64) (float64, int) {
+	defer ann.SetTraining(ann.Training())
+	ann.SetTraining(true)
+
+	output := ann.Forward(inputs)
+	loss, correct := ann.scoreBatch(output, expectedOneHotEncodings)
+
+	ann.AccumulateGradients(expectedOneHotEncodings)
+	ScaleGradients(ann.Parameters(), 1/float64(inputs.Shape[0]))
+	ann.Update(learningRate)
+
+	return loss, correct
+}
+
+// Evaluate scores a batch like TrainBatch in inference mode, without changing the model
+func (ann *ANN) Evaluate(inputs, expectedOneHotEncodings *tensor.Tensor) (float64, int) {
+	defer ann.SetTraining(ann.Training())
+	ann.SetTraining(false)
+
+	return ann.scoreBatch(ann.Forward(inputs), expectedOneHotEncodings)
+}
+
+func (ann *ANN) scoreBatch(output, expectedOneHotEncodings *tensor.Tensor) (float64, int) {
+	loss := float64(output.Shape[0]) * ann.Penalty()
+	correct := 0
+
+	for n := range output.Shape[0] {
+		expected := expectedOneHotEncodings.Row(n)
+		loss += common.CrossEntropyLoss(expected, output.Row(n))
+		if common.Argmax(output.Row(n)) == common.Argmax(expected) {
+			correct++
+		}
+	}
+
+	return loss, correct
+}
@@ -0,0 +1,100 @@
+package neuron
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"ocr_cnn/pkg/common"
+	"ocr_cnn/pkg/tensor"
+	"slices"
+	"testing"
+)
+
+func TestTrainBatchAppliesTheMeanGradient(t *testing.T) {
+	randomFunc := func(int) float64 { return .1 }
+	single := CreateANN(randomFunc, 4, 1)
+	batched := CreateANN(randomFunc, 4, 1)
+
+	input := []float64{1, 0, 1, 1}
+	expected := make([]float64, 10)
+	expected[2] = 1
+
+	single.TrainBatch(tensor.FromSlice(input, 1, 4), tensor.FromSlice(expected, 1, 10), .5)
+	batched.TrainBatch(
+		tensor.FromSlice(slices.Concat(input, input), 2, 4),
+		tensor.FromSlice(slices.Concat(expected, expected), 2, 10),
+		.5,
+	)
+
+	singleParameters, batchedParameters := single.Parameters(), batched.Parameters()
+	for p := range singleParameters {
+		for i, value := range singleParameters[p].Value.Data {
+			if relativeError(value, batchedParameters[p].Value.Data[i]) > 1e-12 {
+				t.Fatalf("parameter %d value %d: expected %f but got %f", p, i, value, batchedParameters[p].Value.Data[i])
+			}
+		}
+	}
+}
+
+func TestTrainBatchLearnsToSeparateTwoClasses(t *testing.T) {
+	rng := rand.New(rand.NewPCG(21, 22))
+	ann := CreateANN(func(fanInSize int) float64 {
+		return rng.NormFloat64() / float64(fanInSize)
+	}, 8, 1)
+
+	inputs := tensor.FromSlice([]float64{
+		1, 1, 1, 1, 0, 0, 0, 0,
+		0, 0, 0, 0, 1, 1, 1, 1,
+	}, 2, 8)
+	expected := tensor.New(2, 10)
+	expected.Set(1, 0, 3)
+	expected.Set(1, 1, 8)
+
+	initialLoss, _ := ann.Evaluate(inputs, expected)
+	correct := 0
+	for range 100 {
+		_, correct = ann.TrainBatch(inputs, expected, .5)
+	}
+	finalLoss, finalCorrect := ann.Evaluate(inputs, expected)
+
+	if finalLoss >= initialLoss {
+		t.Errorf("expected loss to decrease from %f but was %f", initialLoss, finalLoss)
+	}
+	if correct != 2 || finalCorrect != 2 {
+		t.Errorf("expected both samples to be classified correctly but got %d", finalCorrect)
+	}
+}
+
+func TestTrainingWithTheSameSeedSavesIdenticalModels(t *testing.T) {
+	train := func(seed uint64) []byte {
+		ann := CreateANN(common.NormalDistributionHe(common.NewRand(seed, common.InitStream)), 16, 2)
+		ann.Optimizer = CreateAdam(.9, .999, 1e-8)
+
+		shuffleRng := common.NewRand(seed, common.ShuffleStream)
+		samples := []int{0, 1, 2, 3, 4, 5}
+		for range 3 {
+			shuffleRng.Shuffle(len(samples), func(i, j int) {
+				samples[i], samples[j] = samples[j], samples[i]
+			})
+			for _, sample := range samples {
+				input := tensor.New(1, 16)
+				input.Data[sample] = 1
+				expected := tensor.New(1, 10)
+				expected.Data[sample] = 1
+				ann.TrainBatch(input, expected, .01)
+			}
+		}
+
+		buffer := &bytes.Buffer{}
+		if err := Save(buffer, &ann); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.Bytes()
+	}
+
+	if !bytes.Equal(train(1), train(1)) {
+		t.Errorf("expected the same seed to save identical models")
+	}
+	if bytes.Equal(train(1), train(2)) {
+		t.Errorf("expected different seeds to save different models")
+	}
+}
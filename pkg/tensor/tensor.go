@@ -0,0 +1,205 @@
+package tensor
+
+import (
+	"fmt"
+	"ocr_cnn/pkg/common"
+	"slices"
+)
+
+// Tensor is a view over contiguous float64 storage. Strides say how far apart
+// neighbouring values of every dimension are in Data, so views such as
+// Transpose share storage with the tensor they came from
+type Tensor struct {
+	Data    []float64
+	Shape   []int
+	Strides []int
+}
+
+func New(shape ...int) *Tensor {
+	return FromSlice(make([]float64, size(shape)), shape...)
+}
+
+// FromSlice wraps data without copying it
+func FromSlice(data []float64, shape ...int) *Tensor {
+	if len(data) != size(shape) {
+		common.PrintAndTerminate(fmt.Sprintf("data of size %d does not fit shape %v", len(data), shape))
+	}
+
+	return &Tensor{
+		Data:    data,
+		Shape:   slices.Clone(shape),
+		Strides: contiguousStrides(shape),
+	}
+}
+
+func size(shape []int) int {
+	n := 1
+	for _, dimension := range shape {
+		n *= dimension
+	}
+	return n
+}
+
+func contiguousStrides(shape []int) []int {
+	strides := make([]int, len(shape))
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = stride
+		stride *= shape[i]
+	}
+	return strides
+}
+
+func (t *Tensor) Len() int {
+	return size(t.Shape)
+}
+
+func (t *Tensor) IsContiguous() bool {
+	return slices.Equal(t.Strides, contiguousStrides(t.Shape))
+}
+
+func (t *Tensor) offset(indices []int) int {
+	if len(indices) != len(t.Shape) {
+		common.PrintAndTerminate(fmt.Sprintf("%d indices used on tensor of shape %v", len(indices), t.Shape))
+	}
+
+	offset := 0
+	for i, index := range indices {
+		if index < 0 || index >= t.Shape[i] {
+			common.PrintAndTerminate(fmt.Sprintf("index %v out of range for shape %v", indices, t.Shape))
+		}
+		offset += index * t.Strides[i]
+	}
+	return offset
+}
+
+func (t *Tensor) At(indices ...int) float64 {
+	return t.Data[t.offset(indices)]
+}
+
+func (t *Tensor) Set(value float64, indices ...int) {
+	t.Data[t.offset(indices)] = value
+}
+
+// Reshape returns a view with a new shape. only contiguous tensors can be reshaped
+func (t *Tensor) Reshape(shape ...int) *Tensor {
+	if !t.IsContiguous() {
+		common.PrintAndTerminate("cannot reshape a non contiguous tensor")
+	}
+	return FromSlice(t.Data, shape...)
+}
+
+// Transpose returns a view of a 2 dimensional tensor with its rows and columns swapped
+func (t *Tensor) Transpose() *Tensor {
+	if len(t.Shape) != 2 {
+		common.PrintAndTerminate(fmt.Sprintf("cannot transpose tensor of shape %v", t.Shape))
+	}
+
+	return &Tensor{
+		Data:    t.Data,
+		Shape:   []int{t.Shape[1], t.Shape[0]},
+		Strides: []int{t.Strides[1], t.Strides[0]},
+	}
+}
+
+// Clone returns a contiguous copy
+func (t *Tensor) Clone() *Tensor {
+	clone := New(t.Shape...)
+
+	if t.IsContiguous() {
+		copy(clone.Data, t.Data)
+		return clone
+	}
+
+	indices := make([]int, len(t.Shape))
+	for i := range clone.Data {
+		clone.Data[i] = t.Data[t.offset(indices)]
+		for d := len(indices) - 1; d >= 0; d-- {
+			indices[d]++
+			if indices[d] < t.Shape[d] {
+				break
+			}
+			indices[d] = 0
+		}
+	}
+	return clone
+}
+
+// Row returns the storage of row i of a contiguous 2 dimensional tensor
+func (t *Tensor) Row(i int) []float64 {
+	if len(t.Shape) != 2 || !t.IsContiguous() {
+		common.PrintAndTerminate(fmt.Sprintf("cannot take a row of tensor of shape %v", t.Shape))
+	}
+
+	columns := t.Shape[1]
+	return t.Data[i*columns : (i+1)*columns]
+}
+
+// Add adds other to t element by element. both must be contiguous and the same shape
+func (t *Tensor) Add(other *Tensor) {
+	if !slices.Equal(t.Shape, other.Shape) || !t.IsContiguous() || !other.IsContiguous() {
+		common.PrintAndTerminate(fmt.Sprintf("cannot add tensor of shape %v to %v", other.Shape, t.Shape))
+	}
+
+	for i, value := range other.Data {
+		t.Data[i] += value
+	}
+}
+
+func (t *Tensor) Fill(value float64) {
+	for i := range t.Data {
+		t.Data[i] = value
+	}
+}
+
+// MatMul multiplies two 2 dimensional tensors into a new contiguous tensor
+func MatMul(a, b *Tensor) *Tensor {
+	if len(a.Shape) != 2 || len(b.Shape) != 2 || a.Shape[1] != b.Shape[0] {
+		common.PrintAndTerminate(fmt.Sprintf("cannot multiply tensors of shape %v and %v", a.Shape, b.Shape))
+	}
+
+	rows, inner, columns := a.Shape[0], a.Shape[1], b.Shape[1]
+	out := New(rows, columns)
+
+	aRowStride, aColumnStride := a.Strides[0], a.Strides[1]
+	bRowStride, bColumnStride := b.Strides[0], b.Strides[1]
+
+	switch {
+	case bColumnStride == 1:
+		// rows of b are contiguous, so scale and add them into each output row
+		for i := range rows {
+			outRow := out.Data[i*columns : (i+1)*columns]
+			for k := range inner {
+				scalar := a.Data[i*aRowStride+k*aColumnStride]
+				bRow := b.Data[k*bRowStride : k*bRowStride+columns]
+				for j, value := range bRow {
+					outRow[j] += scalar * value
+				}
+			}
+		}
+	case bRowStride == 1:
+		// b is a transposed view, so its columns are contiguous and each output is a dot product
+		for i := range rows {
+			for j := range columns {
+				bColumn := b.Data[j*bColumnStride : j*bColumnStride+inner]
+				sum := float64(0)
+				for k, value := range bColumn {
+					sum += a.Data[i*aRowStride+k*aColumnStride] * value
+				}
+				out.Data[i*columns+j] = sum
+			}
+		}
+	default:
+		for i := range rows {
+			for j := range columns {
+				sum := float64(0)
+				for k := range inner {
+					sum += a.Data[i*aRowStride+k*aColumnStride] * b.Data[k*bRowStride+j*bColumnStride]
+				}
+				out.Data[i*columns+j] = sum
+			}
+		}
+	}
+
+	return out
+}
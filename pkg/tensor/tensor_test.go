@@ -0,0 +1,134 @@
+package tensor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewIsZeroedAndContiguous(t *testing.T) {
+	tensor := New(2, 3, 4)
+
+	if tensor.Len() != 24 || len(tensor.Data) != 24 {
+		t.Errorf("expected 24 values but got %d", tensor.Len())
+	}
+	if !slices.Equal(tensor.Strides, []int{12, 4, 1}) {
+		t.Errorf("expected strides [12 4 1] but got %v", tensor.Strides)
+	}
+	for _, value := range tensor.Data {
+		if value != 0 {
+			t.Fatalf("expected tensor to start at zero")
+		}
+	}
+}
+
+func TestAtAndSetUseStrides(t *testing.T) {
+	tensor := FromSlice([]float64{
+		1, 2, 3,
+		4, 5, 6,
+	}, 2, 3)
+
+	if tensor.At(1, 2) != 6 {
+		t.Errorf("expected 6 but got %f", tensor.At(1, 2))
+	}
+
+	tensor.Set(9, 0, 1)
+	if tensor.Data[1] != 9 {
+		t.Errorf("expected set to write into storage but got %v", tensor.Data)
+	}
+}
+
+func TestTransposeIsAViewThatSharesStorage(t *testing.T) {
+	tensor := FromSlice([]float64{
+		1, 2, 3,
+		4, 5, 6,
+	}, 2, 3)
+
+	transposed := tensor.Transpose()
+	if !slices.Equal(transposed.Shape, []int{3, 2}) {
+		t.Errorf("expected shape [3 2] but got %v", transposed.Shape)
+	}
+	if transposed.At(2, 0) != 3 || transposed.At(0, 1) != 4 {
+		t.Errorf("expected transposed values but got %f and %f", transposed.At(2, 0), transposed.At(0, 1))
+	}
+	if transposed.IsContiguous() {
+		t.Errorf("expected transposed view to not be contiguous")
+	}
+
+	transposed.Set(7, 1, 1)
+	if tensor.At(1, 1) != 7 {
+		t.Errorf("expected transposed view to share storage")
+	}
+
+	clone := transposed.Clone()
+	if !clone.IsContiguous() || !slices.Equal(clone.Data, []float64{1, 4, 2, 7, 3, 6}) {
+		t.Errorf("expected contiguous clone [1 4 2 7 3 6] but got %v", clone.Data)
+	}
+}
+
+func TestReshapeAndRow(t *testing.T) {
+	tensor := FromSlice([]float64{1, 2, 3, 4, 5, 6}, 6)
+
+	reshaped := tensor.Reshape(3, 2)
+	if !slices.Equal(reshaped.Row(1), []float64{3, 4}) {
+		t.Errorf("expected row [3 4] but got %v", reshaped.Row(1))
+	}
+}
+
+func TestMatMul(t *testing.T) {
+	a := FromSlice([]float64{
+		1, 2, 3,
+		4, 5, 6,
+	}, 2, 3)
+	b := FromSlice([]float64{
+		7, 8,
+		9, 10,
+		11, 12,
+	}, 3, 2)
+
+	expected := []float64{
+		1*7 + 2*9 + 3*11, 1*8 + 2*10 + 3*12,
+		4*7 + 5*9 + 6*11, 4*8 + 5*10 + 6*12,
+	}
+
+	for name, product := range map[string]*Tensor{
+		"contiguous":   MatMul(a, b),
+		"transposed b": MatMul(a, b.Transpose().Clone().Transpose()),
+		"transposed a": MatMul(a.Transpose().Clone().Transpose(), b),
+	} {
+		if !slices.Equal(product.Shape, []int{2, 2}) {
+			t.Errorf("%s: expected shape [2 2] but got %v", name, product.Shape)
+		}
+		if !slices.Equal(product.Data, expected) {
+			t.Errorf("%s: expected %v but got %v", name, expected, product.Data)
+		}
+	}
+}
+
+func TestMatMulWithStridedViews(t *testing.T) {
+	// a sub view of every other column forces the generic path
+	storage := FromSlice([]float64{
+		1, 0, 2,
+		3, 0, 4,
+	}, 2, 3)
+	b := &Tensor{Data: storage.Data, Shape: []int{2, 2}, Strides: []int{3, 2}}
+	a := FromSlice([]float64{1, 1}, 1, 2)
+
+	product := MatMul(a, b)
+	if !slices.Equal(product.Data, []float64{4, 6}) {
+		t.Errorf("expected [4 6] but got %v", product.Data)
+	}
+}
+
+func TestAddAndFill(t *testing.T) {
+	tensor := FromSlice([]float64{1, 2}, 2)
+	tensor.Add(FromSlice([]float64{3, 4}, 2))
+
+	if !slices.Equal(tensor.Data, []float64{4, 6}) {
+		t.Errorf("expected [4 6] but got %v", tensor.Data)
+	}
+
+	tensor.Fill(0)
+	if !slices.Equal(tensor.Data, []float64{0, 0}) {
+		t.Errorf("expected [0 0] but got %v", tensor.Data)
+	}
+}